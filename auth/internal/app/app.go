@@ -3,6 +3,7 @@ package app
 import (
 	"auth/internal/app/grpcapp"
 	"auth/internal/config"
+	"auth/internal/lib/jwt"
 	"auth/internal/repository"
 	"auth/internal/storage/postgresql"
 	"auth/internal/transport/rest"
@@ -20,14 +21,22 @@ type App struct {
 }
 
 func New(log *zap.SugaredLogger, cfg *config.Config) *App {
+	tokens, err := jwt.NewManager(cfg.JWT)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hasher, err := jwt.NewTokenHasher(cfg.RefreshTokenPepper)
+	if err != nil {
+		log.Fatal(err)
+	}
 	storage, err := postgresql.New(cfg.StorageURL, cfg.MigrationsPath)
 	if err != nil {
 		log.Fatal(err)
 	}
-	repo := repository.New(log, storage, storage, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
-	s := rpc.New(repo)
+	repo := repository.New(log, storage, storage, storage, tokens, hasher, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	s := rpc.New(repo, tokens)
 	GRPCServer := grpcapp.New(net.JoinHostPort(cfg.GRPCServer.Host, cfg.GRPCServer.Port), s)
-	HTTPServer := rest.NewServer(log, repo, cfg.HTTPServer)
+	HTTPServer := rest.NewServer(log, repo, tokens, cfg.HTTPServer)
 	return &App{
 		log:        log,
 		GRPCServer: GRPCServer,
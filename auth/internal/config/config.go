@@ -16,6 +16,10 @@ type Config struct {
 	MigrationsPath  string        `yaml:"migrations_path"`
 	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
 	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	// RefreshTokenPepper keys the hashes refresh tokens are stored under.
+	// It must not be kept in the database.
+	RefreshTokenPepper string    `env:"REFRESH_TOKEN_PEPPER"`
+	JWT                JWTConfig `yaml:"jwt"`
 }
 
 type GRPCConfig struct {
@@ -30,6 +34,26 @@ type HTTPConfig struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout"`
 }
 
+// JWTConfig lists the keys tokens are signed and verified with. With
+// LegacyHS256 set, tokens signed with HS256 and Secret are accepted too,
+// and are issued while no key is active.
+type JWTConfig struct {
+	Keys        []KeyConfig `yaml:"keys"`
+	LegacyHS256 bool        `yaml:"legacy_hs256" env:"JWT_LEGACY_HS256" env-default:"false"`
+	Secret      string      `env:"JWT_SECRET"`
+}
+
+// KeyConfig is a PEM file with an RSA or Ed25519 private key, or with a
+// public key that only verifies tokens. The key signs tokens from
+// ActiveFrom until a key with a later ActiveFrom takes over, and is no
+// longer trusted from RetireAt. ID defaults to a hash of the public key.
+type KeyConfig struct {
+	ID         string    `yaml:"id"`
+	Path       string    `yaml:"path"`
+	ActiveFrom time.Time `yaml:"active_from"`
+	RetireAt   time.Time `yaml:"retire_at"`
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -4,4 +4,5 @@ type User struct {
 	ID       string
 	Email    string
 	PassHash []byte
+	IsAdmin  bool
 }
@@ -0,0 +1,33 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// minPepperSize is the smallest pepper accepted, in bytes.
+const minPepperSize = 32
+
+// TokenHasher hashes refresh tokens before they are stored. The hash is
+// keyed with a pepper that is kept out of the database, so a copy of the
+// refresh_tokens table can neither be replayed nor checked against
+// guessed tokens.
+type TokenHasher struct {
+	pepper []byte
+}
+
+func NewTokenHasher(pepper string) (*TokenHasher, error) {
+	if len(pepper) < minPepperSize {
+		return nil, fmt.Errorf("refresh token pepper must be at least %d bytes", minPepperSize)
+	}
+	return &TokenHasher{pepper: []byte(pepper)}, nil
+}
+
+// HashToken returns the hex-encoded HMAC-SHA256 of token.
+func (h *TokenHasher) HashToken(token string) string {
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,55 @@
+package jwt_test
+
+import (
+	"auth/internal/lib/jwt"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const pepper = "0123456789abcdef0123456789abcdef"
+
+func TestNewTokenHasher(t *testing.T) {
+	cases := []struct {
+		name    string
+		pepper  string
+		wantErr bool
+	}{
+		{name: "Valid pepper", pepper: pepper},
+		{name: "Empty pepper", pepper: "", wantErr: true},
+		{name: "Short pepper", pepper: strings.Repeat("x", 31), wantErr: true},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := jwt.NewTokenHasher(tc.pepper)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTokenHasher(t *testing.T) {
+	h, err := jwt.NewTokenHasher(pepper)
+	require.NoError(t, err)
+	other, err := jwt.NewTokenHasher(strings.Repeat("p", 32))
+	require.NoError(t, err)
+
+	const token = "header.payload.signature"
+	hash := h.HashToken(token)
+
+	require.Regexp(t, regexp.MustCompile(`^[0-9a-f]{64}$`), hash, "the hash fits the column constraint")
+	require.Equal(t, hash, h.HashToken(token), "the hash is deterministic")
+	require.NotEqual(t, hash, h.HashToken(token+"x"))
+
+	sum := sha256.Sum256([]byte(token))
+	require.NotEqual(t, hex.EncodeToString(sum[:]), hash, "the hash is keyed")
+	require.NotEqual(t, hash, other.HashToken(token), "another pepper gives another hash")
+}
@@ -1,34 +1,107 @@
 package jwt
 
 import (
+	"auth/internal/config"
 	"auth/internal/domain"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
-	"os"
 	"time"
 )
 
 const (
 	uidClaim   = "uid"
 	emailClaim = "email"
+	adminClaim = "adm"
 )
 
-func NewToken(user *domain.User, duration time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// Manager signs tokens with the active key and verifies them against every
+// key that is not retired. Keys are switched by their schedule, so rotating
+// a key only takes adding it to the configuration ahead of its ActiveFrom.
+type Manager struct {
+	// keys are ordered by activeFrom.
+	keys []*key
+	// secret is the HS256 secret of the legacy mode, nil otherwise.
+	secret []byte
+}
+
+// NewManager loads the keys listed in cfg.
+func NewManager(cfg config.JWTConfig) (*Manager, error) {
+	m := &Manager{}
+	if cfg.LegacyHS256 {
+		if cfg.Secret == "" {
+			return nil, errors.New("legacy HS256 mode requires JWT_SECRET")
+		}
+		m.secret = []byte(cfg.Secret)
+	}
+
+	ids := make(map[string]bool, len(cfg.Keys))
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		if ids[k.id] {
+			return nil, fmt.Errorf("duplicate key id %q", k.id)
+		}
+		ids[k.id] = true
+		m.keys = append(m.keys, k)
+	}
+	sortKeys(m.keys)
+
+	if m.secret == nil && !m.canSign() {
+		return nil, errors.New("no signing key configured and legacy HS256 mode is off")
+	}
+	return m, nil
+}
+
+func (m *Manager) canSign() bool {
+	for _, k := range m.keys {
+		if k.private != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// activeKey returns the signing key with the latest ActiveFrom that is not
+// after now, skipping retired and verification-only keys.
+func (m *Manager) activeKey(now time.Time) *key {
+	var active *key
+	for _, k := range m.keys {
+		if k.activeFrom.After(now) {
+			break
+		}
+		if k.private != nil && !k.retired(now) {
+			active = k
+		}
+	}
+	return active
+}
+
+func (m *Manager) NewToken(user *domain.User, duration time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
 		uidClaim:   user.ID,
 		emailClaim: user.Email,
-		"exp":      time.Now().Add(duration).Unix(),
-	})
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+		adminClaim: user.IsAdmin,
+		"exp":      now.Add(duration).Unix(),
+	}
+	if k := m.activeKey(now); k != nil {
+		token := jwt.NewWithClaims(k.method, claims)
+		token.Header["kid"] = k.id
+		return token.SignedString(k.private)
+	}
+	if m.secret != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	}
+	return "", ErrNoSigningKey
 }
-func VerifyToken(tokenString string) (*domain.User, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+
+func (m *Manager) VerifyToken(tokenString string) (*domain.User, error) {
+	token, err := jwt.Parse(tokenString, m.verificationKey)
 
 	if err != nil {
 		switch {
@@ -64,15 +137,36 @@ func VerifyToken(tokenString string) (*domain.User, error) {
 		return nil, fmt.Errorf("invalid email claim")
 	}
 
+	// Tokens issued before the claim was added have no adm claim.
+	isAdmin, _ := claims[adminClaim].(bool)
+
 	user := &domain.User{
-		ID:    uid,
-		Email: email,
+		ID:      uid,
+		Email:   email,
+		IsAdmin: isAdmin,
 	}
 
 	return user, nil
 }
 
-// TODO: доделать хэширование
-func HashToken(token string) (string, error) {
-	return token, nil
+func (m *Manager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if m.secret == nil || token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("%v tokens are not accepted", token.Header["alg"])
+		}
+		return m.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	now := time.Now()
+	for _, k := range m.keys {
+		if k.id != kid || k.retired(now) {
+			continue
+		}
+		if k.method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("key %s does not match signing method %v", kid, token.Header["alg"])
+		}
+		return k.public, nil
+	}
+	return nil, fmt.Errorf("unknown key: %v", token.Header["kid"])
 }
@@ -0,0 +1,241 @@
+package jwt_test
+
+import (
+	"auth/internal/config"
+	"auth/internal/domain"
+	"auth/internal/lib/jwt"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var user = &domain.User{ID: "42", Email: "user@example.com", IsAdmin: true}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func rsaKeyFile(t *testing.T, bits int) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	return writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+// ed25519KeyFiles returns the private key file and the file with only its
+// public half.
+func ed25519KeyFiles(t *testing.T) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return writePEM(t, "PRIVATE KEY", privDER), writePEM(t, "PUBLIC KEY", pubDER)
+}
+
+func kid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwtlib.NewParser().ParseUnverified(token, jwtlib.MapClaims{})
+	require.NoError(t, err)
+	id, _ := parsed.Header["kid"].(string)
+	return id
+}
+
+func publishedIDs(m *jwt.Manager) []string {
+	var ids []string
+	for _, k := range m.PublicKeys().Keys {
+		ids = append(ids, k.Kid)
+	}
+	return ids
+}
+
+func TestNewManager(t *testing.T) {
+	rsaKey := rsaKeyFile(t, 2048)
+	_, publicOnly := ed25519KeyFiles(t)
+	cases := []struct {
+		name    string
+		cfg     config.JWTConfig
+		wantErr bool
+	}{
+		{
+			name: "RSA key",
+			cfg:  config.JWTConfig{Keys: []config.KeyConfig{{Path: rsaKey}}},
+		},
+		{
+			name: "Legacy only",
+			cfg:  config.JWTConfig{LegacyHS256: true, Secret: "secret"},
+		},
+		{
+			name:    "No keys",
+			cfg:     config.JWTConfig{Secret: "secret"},
+			wantErr: true,
+		},
+		{
+			name:    "Only a public key",
+			cfg:     config.JWTConfig{Keys: []config.KeyConfig{{Path: publicOnly}}},
+			wantErr: true,
+		},
+		{
+			name:    "Legacy without secret",
+			cfg:     config.JWTConfig{LegacyHS256: true},
+			wantErr: true,
+		},
+		{
+			name:    "Short RSA key",
+			cfg:     config.JWTConfig{Keys: []config.KeyConfig{{Path: rsaKeyFile(t, 1024)}}},
+			wantErr: true,
+		},
+		{
+			name: "Duplicate id",
+			cfg: config.JWTConfig{Keys: []config.KeyConfig{
+				{ID: "k1", Path: rsaKey},
+				{ID: "k1", Path: rsaKeyFile(t, 2048)},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "Missing file",
+			cfg:     config.JWTConfig{Keys: []config.KeyConfig{{Path: filepath.Join(t.TempDir(), "missing.pem")}}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := jwt.NewManager(tc.cfg)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestManagerRotation(t *testing.T) {
+	now := time.Now()
+	oldKey, currentKey, nextKey := rsaKeyFile(t, 2048), rsaKeyFile(t, 2048), rsaKeyFile(t, 2048)
+
+	before, err := jwt.NewManager(config.JWTConfig{Keys: []config.KeyConfig{
+		{ID: "old", Path: oldKey, ActiveFrom: now.Add(-2 * time.Hour)},
+	}})
+	require.NoError(t, err)
+	oldToken, err := before.NewToken(user, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "old", kid(t, oldToken))
+
+	m, err := jwt.NewManager(config.JWTConfig{Keys: []config.KeyConfig{
+		{ID: "next", Path: nextKey, ActiveFrom: now.Add(time.Hour)},
+		{ID: "old", Path: oldKey, ActiveFrom: now.Add(-2 * time.Hour)},
+		{ID: "current", Path: currentKey, ActiveFrom: now.Add(-time.Hour)},
+	}})
+	require.NoError(t, err)
+
+	token, err := m.NewToken(user, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "current", kid(t, token), "the latest key that is already active signs")
+
+	got, err := m.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, user, got)
+
+	_, err = m.VerifyToken(oldToken)
+	require.NoError(t, err, "tokens of the previous key stay valid")
+
+	require.ElementsMatch(t, []string{"old", "current", "next"}, publishedIDs(m),
+		"the next key is published before it signs")
+}
+
+func TestManagerRetiredKey(t *testing.T) {
+	now := time.Now()
+	oldKey, currentKey := rsaKeyFile(t, 2048), rsaKeyFile(t, 2048)
+
+	before, err := jwt.NewManager(config.JWTConfig{Keys: []config.KeyConfig{{ID: "old", Path: oldKey}}})
+	require.NoError(t, err)
+	oldToken, err := before.NewToken(user, time.Hour)
+	require.NoError(t, err)
+
+	m, err := jwt.NewManager(config.JWTConfig{Keys: []config.KeyConfig{
+		{ID: "old", Path: oldKey, RetireAt: now.Add(-time.Minute)},
+		{ID: "current", Path: currentKey, ActiveFrom: now.Add(-time.Hour)},
+	}})
+	require.NoError(t, err)
+
+	_, err = m.VerifyToken(oldToken)
+	require.Error(t, err)
+	require.Equal(t, []string{"current"}, publishedIDs(m))
+}
+
+func TestManagerEdDSA(t *testing.T) {
+	private, public := ed25519KeyFiles(t)
+
+	signer, err := jwt.NewManager(config.JWTConfig{Keys: []config.KeyConfig{{ID: "ed", Path: private}}})
+	require.NoError(t, err)
+	token, err := signer.NewToken(user, time.Hour)
+	require.NoError(t, err)
+
+	parsed, _, err := jwtlib.NewParser().ParseUnverified(token, jwtlib.MapClaims{})
+	require.NoError(t, err)
+	require.Equal(t, "EdDSA", parsed.Method.Alg())
+
+	keys := signer.PublicKeys().Keys
+	require.Len(t, keys, 1)
+	require.Equal(t, "OKP", keys[0].Kty)
+	require.Equal(t, "Ed25519", keys[0].Crv)
+
+	verifier, err := jwt.NewManager(config.JWTConfig{
+		Keys: []config.KeyConfig{
+			{ID: "ed", Path: public},
+			{Path: rsaKeyFile(t, 2048)},
+		},
+	})
+	require.NoError(t, err)
+	got, err := verifier.VerifyToken(token)
+	require.NoError(t, err, "a public key file verifies tokens")
+	require.Equal(t, user, got)
+}
+
+func TestManagerLegacyHS256(t *testing.T) {
+	rsaKey := rsaKeyFile(t, 2048)
+
+	legacy, err := jwt.NewManager(config.JWTConfig{LegacyHS256: true, Secret: "secret"})
+	require.NoError(t, err)
+	hsToken, err := legacy.NewToken(user, time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, kid(t, hsToken))
+	require.Empty(t, legacy.PublicKeys().Keys, "the secret is never published")
+
+	migrating, err := jwt.NewManager(config.JWTConfig{
+		Keys:        []config.KeyConfig{{ID: "rsa", Path: rsaKey}},
+		LegacyHS256: true,
+		Secret:      "secret",
+	})
+	require.NoError(t, err)
+	token, err := migrating.NewToken(user, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "rsa", kid(t, token), "keys take precedence over the secret")
+	_, err = migrating.VerifyToken(hsToken)
+	require.NoError(t, err)
+
+	strict, err := jwt.NewManager(config.JWTConfig{
+		Keys:   []config.KeyConfig{{ID: "rsa", Path: rsaKey}},
+		Secret: "secret",
+	})
+	require.NoError(t, err)
+	_, err = strict.VerifyToken(hsToken)
+	require.Error(t, err, "HS256 tokens are rejected outside the legacy mode")
+}
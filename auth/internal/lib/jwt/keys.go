@@ -0,0 +1,109 @@
+package jwt
+
+import (
+	"auth/internal/config"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"sort"
+	"time"
+)
+
+// minRSABits is the smallest RSA key accepted.
+const minRSABits = 2048
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	// private is nil for keys that only verify tokens.
+	private    crypto.PrivateKey
+	public     crypto.PublicKey
+	activeFrom time.Time
+	retireAt   time.Time
+}
+
+func (k *key) retired(now time.Time) bool {
+	return !k.retireAt.IsZero() && !now.Before(k.retireAt)
+}
+
+func sortKeys(keys []*key) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].activeFrom.Before(keys[j].activeFrom)
+	})
+}
+
+// loadKey reads a PEM file with an RSA or Ed25519 private key in PKCS #1
+// or PKCS #8 form, or a PKIX public key.
+func loadKey(cfg config.KeyConfig) (*key, error) {
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", cfg.Path)
+	}
+
+	k := &key{
+		id:         cfg.ID,
+		activeFrom: cfg.ActiveFrom,
+		retireAt:   cfg.RetireAt,
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k.private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k.private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		k.public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, cfg.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", cfg.Path, err)
+	}
+
+	if k.private != nil {
+		signer, ok := k.private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type in %s", cfg.Path)
+		}
+		k.public = signer.Public()
+	}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key in %s is shorter than %d bits", cfg.Path, minRSABits)
+		}
+		k.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("key in %s is neither RSA nor Ed25519", cfg.Path)
+	}
+
+	if k.id == "" {
+		if k.id, err = keyID(k.public); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// keyID derives the kid of a public key from its DER encoding, so a key
+// keeps its kid across restarts.
+func keyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
@@ -0,0 +1,47 @@
+// Package pat generates and hashes personal access tokens.
+package pat
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Prefix starts every personal access token, which tells them apart from JWTs.
+const Prefix = "lk_"
+
+// Scopes a personal access token may be granted.
+const (
+	ScopeLinksRead  = "links:read"
+	ScopeLinksWrite = "links:write"
+)
+
+// IsScope reports whether scope is a known scope.
+func IsScope(scope string) bool {
+	return scope == ScopeLinksRead || scope == ScopeLinksWrite
+}
+
+// IsToken reports whether token looks like a personal access token.
+func IsToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// New returns a random token and its hash.
+func New() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = Prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, Hash(token), nil
+}
+
+// Hash returns the hex-encoded SHA-256 hash of token, which is what gets
+// stored. Tokens are random, so a plain hash is enough.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
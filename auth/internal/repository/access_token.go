@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"auth/internal/lib/pat"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// lastUsedPrecision is how stale last_used_at may get before a use of the
+// token is recorded, so that busy tokens do not cost a write per request.
+const lastUsedPrecision = time.Minute
+
+// CreateAccessToken issues a personal access token for the user and returns
+// it together with its stored attributes. The token itself cannot be
+// retrieved later.
+func (r *Repository) CreateAccessToken(
+	ctx context.Context,
+	userID int64,
+	name string,
+	scopes []string,
+	expiresAt *time.Time,
+) (string, *storage.AccessToken, error) {
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !pat.IsScope(scope) {
+			return "", nil, ErrInvalidScope
+		}
+	}
+
+	token, hash, err := pat.New()
+	if err != nil {
+		return "", nil, err
+	}
+	at := &storage.AccessToken{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err = r.patStorage.SaveAccessToken(ctx, hash, at); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return "", nil, ErrInvalidCredentials
+		}
+		return "", nil, fmt.Errorf("failed to save access token: %w", err)
+	}
+	return token, at, nil
+}
+
+func (r *Repository) ListAccessTokens(ctx context.Context, userID int64) ([]storage.AccessToken, error) {
+	tokens, err := r.patStorage.ListAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *Repository) RevokeAccessToken(ctx context.Context, userID, id int64) error {
+	if err := r.patStorage.DeleteAccessToken(ctx, userID, id); err != nil {
+		if errors.Is(err, storage.ErrAccessTokenNotFound) {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+	return nil
+}
+
+// ValidateAccessToken returns the attributes of an unexpired personal access
+// token and records that it was used, at most once per lastUsedPrecision.
+func (r *Repository) ValidateAccessToken(ctx context.Context, token string) (*storage.AccessToken, error) {
+	at, err := r.patStorage.GetAccessToken(ctx, pat.Hash(token))
+	if err != nil {
+		if errors.Is(err, storage.ErrAccessTokenNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	now := time.Now()
+	if at.ExpiresAt != nil && !now.Before(*at.ExpiresAt) {
+		return nil, ErrInvalidCredentials
+	}
+
+	if at.LastUsedAt != nil && now.Sub(*at.LastUsedAt) < lastUsedPrecision {
+		return at, nil
+	}
+	if err = r.patStorage.TouchAccessToken(ctx, at.ID, now); err != nil {
+		r.log.Warn("failed to record access token use", zap.Int64("id", at.ID), zap.Error(err))
+	} else {
+		at.LastUsedAt = &now
+	}
+	return at, nil
+}
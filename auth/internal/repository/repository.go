@@ -2,7 +2,6 @@ package repository
 
 import (
 	"auth/internal/domain"
-	"auth/internal/lib/jwt"
 	"auth/internal/storage"
 	"context"
 	"errors"
@@ -19,31 +18,56 @@ type UserStorage interface {
 	DeleteAccount(ctx context.Context, userID int64) error
 }
 type RefreshTokenStorage interface {
-	StoreRefreshToken(ctx context.Context, userID string, token string, expiresAt time.Time) error
+	StoreRefreshToken(ctx context.Context, userID string, tokenHash string, expiresAt time.Time) error
 	GetRefreshToken(ctx context.Context, tokenHash string) (*storage.RefreshToken, error)
-	ValidateRefreshToken(ctx context.Context, token string) (*storage.RefreshToken, error)
+	ValidateRefreshToken(ctx context.Context, tokenHash string) (*storage.RefreshToken, error)
 	DeleteRefreshToken(ctx context.Context, tokenHash string) error
 	DeleteRefreshTokenByUserID(ctx context.Context, userID int64) error
 	DeleteExpiredRefreshTokens(ctx context.Context) error
 }
+type AccessTokenStorage interface {
+	SaveAccessToken(ctx context.Context, tokenHash string, token *storage.AccessToken) error
+	GetAccessToken(ctx context.Context, tokenHash string) (*storage.AccessToken, error)
+	ListAccessTokens(ctx context.Context, userID int64) ([]storage.AccessToken, error)
+	DeleteAccessToken(ctx context.Context, userID, id int64) error
+	TouchAccessToken(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+type TokenIssuer interface {
+	NewToken(user *domain.User, duration time.Duration) (string, error)
+}
+
+// TokenHasher hashes refresh tokens, which are only ever stored and looked
+// up by their hash.
+type TokenHasher interface {
+	HashToken(token string) string
+}
 
 type Repository struct {
 	log             *zap.SugaredLogger
 	userStorage     UserStorage
 	tokenStorage    RefreshTokenStorage
+	patStorage      AccessTokenStorage
+	tokens          TokenIssuer
+	hasher          TokenHasher
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 }
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrTokenNotFound      = errors.New("token not found")
+	ErrInvalidScope       = errors.New("invalid scope")
 )
 
-func New(log *zap.SugaredLogger, userStorage UserStorage, tokenStorage RefreshTokenStorage, AccessTokenTTL time.Duration, RefreshTokenTTL time.Duration) *Repository {
+func New(log *zap.SugaredLogger, userStorage UserStorage, tokenStorage RefreshTokenStorage, patStorage AccessTokenStorage, tokens TokenIssuer, hasher TokenHasher, AccessTokenTTL time.Duration, RefreshTokenTTL time.Duration) *Repository {
 	return &Repository{
 		log:             log,
 		userStorage:     userStorage,
 		tokenStorage:    tokenStorage,
+		patStorage:      patStorage,
+		tokens:          tokens,
+		hasher:          hasher,
 		AccessTokenTTL:  AccessTokenTTL,
 		RefreshTokenTTL: RefreshTokenTTL,
 	}
@@ -77,17 +101,17 @@ func (r *Repository) Login(ctx context.Context, email, password string) (string,
 		return "", "", ErrInvalidCredentials
 	}
 
-	accessToken, err := jwt.NewToken(user, r.AccessTokenTTL)
+	accessToken, err := r.tokens.NewToken(user, r.AccessTokenTTL)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := jwt.NewToken(user, r.RefreshTokenTTL)
+	refreshToken, err := r.tokens.NewToken(user, r.RefreshTokenTTL)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	err = r.tokenStorage.StoreRefreshToken(ctx, user.ID, refreshToken, time.Now().Add(r.RefreshTokenTTL))
+	err = r.tokenStorage.StoreRefreshToken(ctx, user.ID, r.hasher.HashToken(refreshToken), time.Now().Add(r.RefreshTokenTTL))
 	if err != nil {
 		return "", "", fmt.Errorf("failed to store refresh token: %w", err)
 	}
@@ -96,7 +120,7 @@ func (r *Repository) Login(ctx context.Context, email, password string) (string,
 }
 
 func (r *Repository) RefreshTokens(ctx context.Context, refreshToken string) (string, string, error) {
-	rt, err := r.tokenStorage.ValidateRefreshToken(ctx, refreshToken)
+	rt, err := r.tokenStorage.ValidateRefreshToken(ctx, r.hasher.HashToken(refreshToken))
 	if err != nil {
 		if errors.Is(err, storage.ErrTokenNotFound) || errors.Is(err, storage.ErrTokenExpired) {
 			return "", "", ErrInvalidCredentials
@@ -112,12 +136,12 @@ func (r *Repository) RefreshTokens(ctx context.Context, refreshToken string) (st
 		return "", "", fmt.Errorf("failed to get user: %w", err)
 	}
 
-	newAccessToken, err := jwt.NewToken(user, r.AccessTokenTTL)
+	newAccessToken, err := r.tokens.NewToken(user, r.AccessTokenTTL)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate new access token: %w", err)
 	}
 
-	newRefreshToken, err := jwt.NewToken(user, r.RefreshTokenTTL)
+	newRefreshToken, err := r.tokens.NewToken(user, r.RefreshTokenTTL)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate new refresh token: %w", err)
 	}
@@ -126,7 +150,7 @@ func (r *Repository) RefreshTokens(ctx context.Context, refreshToken string) (st
 		return "", "", fmt.Errorf("failed to delete old refresh token: %w", err)
 	}
 
-	if err = r.tokenStorage.StoreRefreshToken(ctx, user.ID, newRefreshToken, time.Now().Add(r.RefreshTokenTTL)); err != nil {
+	if err = r.tokenStorage.StoreRefreshToken(ctx, user.ID, r.hasher.HashToken(newRefreshToken), time.Now().Add(r.RefreshTokenTTL)); err != nil {
 		return "", "", fmt.Errorf("failed to store new refresh token: %w", err)
 	}
 
@@ -145,11 +169,7 @@ func (r *Repository) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 }
 
 func (r *Repository) Logout(ctx context.Context, token string) error {
-	hash, err := jwt.HashToken(token)
-	if err != nil {
-		return fmt.Errorf("failed to hash token: %w", err)
-	}
-	if err = r.tokenStorage.DeleteRefreshToken(ctx, hash); err != nil {
+	if err := r.tokenStorage.DeleteRefreshToken(ctx, r.hasher.HashToken(token)); err != nil {
 		return fmt.Errorf("failed to delete refresh token: %w", err)
 	}
 	return nil
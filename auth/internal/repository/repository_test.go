@@ -0,0 +1,246 @@
+package repository_test
+
+import (
+	"auth/internal/config"
+	"auth/internal/domain"
+	"auth/internal/lib/jwt"
+	"auth/internal/repository"
+	"auth/internal/storage"
+	"context"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	email    = "user@example.com"
+	password = "password"
+	pepper   = "0123456789abcdef0123456789abcdef"
+)
+
+// memStorage keeps users and refresh tokens the way the database does, so
+// the tests can look at what a dump of it would contain.
+type memStorage struct {
+	repository.AccessTokenStorage
+
+	mu     sync.Mutex
+	user   *domain.User
+	tokens map[string]storage.RefreshToken
+}
+
+func newMemStorage(t *testing.T) *memStorage {
+	t.Helper()
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	return &memStorage{
+		user:   &domain.User{ID: "1", Email: email, PassHash: passHash},
+		tokens: make(map[string]storage.RefreshToken),
+	}
+}
+
+// dump returns the stored token hashes.
+func (s *memStorage) dump() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var hashes []string
+	for hash := range s.tokens {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func (s *memStorage) SaveUser(context.Context, string, []byte) (int64, error) {
+	return 0, storage.ErrUserExists
+}
+
+func (s *memStorage) LoginUser(_ context.Context, email string) (*domain.User, error) {
+	if email != s.user.Email {
+		return nil, storage.ErrUserNotFound
+	}
+	return s.user, nil
+}
+
+func (s *memStorage) IsAdmin(context.Context, int64) (bool, error) {
+	return s.user.IsAdmin, nil
+}
+
+func (s *memStorage) DeleteAccount(context.Context, int64) error {
+	return nil
+}
+
+func (s *memStorage) StoreRefreshToken(_ context.Context, userID string, tokenHash string, expiresAt time.Time) error {
+	id, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenHash] = storage.RefreshToken{
+		TokenHash: tokenHash,
+		UserID:    id,
+		Email:     s.user.Email,
+		ExpiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (s *memStorage) GetRefreshToken(_ context.Context, tokenHash string) (*storage.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.tokens[tokenHash]
+	if !ok {
+		return nil, storage.ErrTokenNotFound
+	}
+	return &rt, nil
+}
+
+func (s *memStorage) ValidateRefreshToken(ctx context.Context, tokenHash string) (*storage.RefreshToken, error) {
+	rt, err := s.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, storage.ErrTokenExpired
+	}
+	return rt, nil
+}
+
+func (s *memStorage) DeleteRefreshToken(_ context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, tokenHash)
+	return nil
+}
+
+func (s *memStorage) DeleteRefreshTokenByUserID(context.Context, int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+	return nil
+}
+
+func (s *memStorage) DeleteExpiredRefreshTokens(context.Context) error {
+	return nil
+}
+
+func newRepository(t *testing.T, s *memStorage, pepper string) *repository.Repository {
+	t.Helper()
+	tokens, err := jwt.NewManager(config.JWTConfig{LegacyHS256: true, Secret: "secret"})
+	require.NoError(t, err)
+	hasher, err := jwt.NewTokenHasher(pepper)
+	require.NoError(t, err)
+	return repository.New(zap.NewNop().Sugar(), s, s, s, tokens, hasher, time.Minute, time.Hour)
+}
+
+func TestRefreshTokensAreHashed(t *testing.T) {
+	s := newMemStorage(t)
+	repo := newRepository(t, s, pepper)
+
+	_, refreshToken, err := repo.Login(context.Background(), email, password)
+	require.NoError(t, err)
+
+	dump := s.dump()
+	require.Len(t, dump, 1)
+	require.NotEqual(t, refreshToken, dump[0], "the token is not stored as is")
+
+	_, newRefreshToken, err := repo.RefreshTokens(context.Background(), refreshToken)
+	require.NoError(t, err, "the issued token refreshes the session")
+
+	dump = s.dump()
+	require.Len(t, dump, 1)
+	require.NotEqual(t, newRefreshToken, dump[0], "the rotated token is not stored as is")
+
+	require.NoError(t, repo.Logout(context.Background(), newRefreshToken))
+	require.Empty(t, s.dump(), "logout finds the token by its hash")
+}
+
+func TestDumpedRefreshTokens(t *testing.T) {
+	s := newMemStorage(t)
+	_, _, err := newRepository(t, s, pepper).Login(context.Background(), email, password)
+	require.NoError(t, err)
+	dump := s.dump()
+	require.Len(t, dump, 1)
+
+	cases := []struct {
+		name   string
+		pepper string
+	}{
+		{name: "Same pepper", pepper: pepper},
+		{name: "Other pepper", pepper: "fedcba9876543210fedcba9876543210"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			repo := newRepository(t, s, tc.pepper)
+
+			_, _, err := repo.RefreshTokens(context.Background(), dump[0])
+			require.ErrorIs(t, err, repository.ErrInvalidCredentials, "a stored hash does not mint a session")
+
+			require.NoError(t, repo.Logout(context.Background(), dump[0]))
+			require.Equal(t, dump, s.dump(), "a stored hash does not end the session")
+		})
+	}
+}
+
+// patStorage holds a single personal access token and counts the recorded
+// uses.
+type patStorage struct {
+	repository.AccessTokenStorage
+
+	mu      sync.Mutex
+	token   storage.AccessToken
+	touches int
+}
+
+func (s *patStorage) GetAccessToken(context.Context, string) (*storage.AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at := s.token
+	return &at, nil
+}
+
+func (s *patStorage) TouchAccessToken(_ context.Context, _ int64, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token.LastUsedAt = &usedAt
+	s.touches++
+	return nil
+}
+
+func TestAccessTokenUseRecordedCoarsely(t *testing.T) {
+	recent := time.Now().Add(-time.Second)
+	stale := time.Now().Add(-time.Hour)
+	cases := []struct {
+		name     string
+		lastUsed *time.Time
+		touches  int
+	}{
+		{name: "Never used", touches: 1},
+		{name: "Used long ago", lastUsed: &stale, touches: 1},
+		{name: "Used recently", lastUsed: &recent, touches: 0},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := newMemStorage(t)
+			pats := &patStorage{token: storage.AccessToken{ID: 1, UserID: 1, LastUsedAt: tc.lastUsed}}
+			tokens, err := jwt.NewManager(config.JWTConfig{LegacyHS256: true, Secret: "secret"})
+			require.NoError(t, err)
+			hasher, err := jwt.NewTokenHasher(pepper)
+			require.NoError(t, err)
+			repo := repository.New(zap.NewNop().Sugar(), s, s, pats, tokens, hasher, time.Minute, time.Hour)
+
+			for i := 0; i < 3; i++ {
+				_, err = repo.ValidateAccessToken(context.Background(), "lk_token")
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.touches, pats.touches)
+		})
+	}
+}
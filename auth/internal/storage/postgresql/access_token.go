@@ -0,0 +1,117 @@
+package postgresql
+
+import (
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+func (s *Storage) SaveAccessToken(ctx context.Context, tokenHash string, token *storage.AccessToken) error {
+	query := `
+		INSERT INTO auth_schema.access_tokens
+		(token_hash, user_id, name, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	err := s.db.QueryRow(ctx, query, tokenHash, token.UserID, token.Name, token.Scopes, token.ExpiresAt).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return storage.ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) GetAccessToken(ctx context.Context, tokenHash string) (*storage.AccessToken, error) {
+	query := `
+		SELECT
+			t.id,
+			t.user_id,
+			u.email,
+			t.name,
+			t.scopes,
+			t.expires_at,
+			t.last_used_at,
+			t.created_at
+		FROM
+			auth_schema.access_tokens t
+		JOIN
+			auth_schema.users u ON t.user_id = u.id
+		WHERE
+			t.token_hash = $1`
+
+	var t storage.AccessToken
+	err := s.db.QueryRow(ctx, query, tokenHash).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Email,
+		&t.Name,
+		&t.Scopes,
+		&t.ExpiresAt,
+		&t.LastUsedAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrAccessTokenNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Storage) ListAccessTokens(ctx context.Context, userID int64) ([]storage.AccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, expires_at, last_used_at, created_at
+		FROM auth_schema.access_tokens
+		WHERE user_id = $1
+		ORDER BY id`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []storage.AccessToken{}
+	for rows.Next() {
+		var t storage.AccessToken
+		if err = rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Scopes, &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// DeleteAccessToken removes the token if it belongs to userID.
+func (s *Storage) DeleteAccessToken(ctx context.Context, userID, id int64) error {
+	query := `
+		DELETE FROM auth_schema.access_tokens
+		WHERE id = $1 AND user_id = $2`
+
+	tag, err := s.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+func (s *Storage) TouchAccessToken(ctx context.Context, id int64, usedAt time.Time) error {
+	query := `
+		UPDATE auth_schema.access_tokens
+		SET last_used_at = $2
+		WHERE id = $1`
+
+	_, err := s.db.Exec(ctx, query, id, usedAt)
+	return err
+}
@@ -59,9 +59,9 @@ func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (
 	return id, nil
 }
 func (s *Storage) LoginUser(ctx context.Context, email string) (*domain.User, error) {
-	query := `SELECT id,email,pass_hash FROM auth_schema.users WHERE email = $1`
+	query := `SELECT id,email,pass_hash,is_admin FROM auth_schema.users WHERE email = $1`
 	user := &domain.User{}
-	err := s.db.QueryRow(ctx, query, email).Scan(&user.ID, &user.Email, &user.PassHash)
+	err := s.db.QueryRow(ctx, query, email).Scan(&user.ID, &user.Email, &user.PassHash, &user.IsAdmin)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrUserNotFound
@@ -99,6 +99,10 @@ func (s *Storage) DeleteAccount(ctx context.Context, userID int64) error {
 		return fmt.Errorf("failed to delete refresh tokens: %w", err)
 	}
 
+	if _, err := tx.Exec(ctx, "DELETE FROM auth_schema.access_tokens WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to delete access tokens: %w", err)
+	}
+
 	if _, err := tx.Exec(ctx, "DELETE FROM auth_schema.users WHERE id = $1", userID); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
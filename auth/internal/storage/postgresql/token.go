@@ -1,7 +1,6 @@
 package postgresql
 
 import (
-	"auth/internal/lib/jwt"
 	"auth/internal/storage"
 	"context"
 	"errors"
@@ -13,21 +12,16 @@ import (
 func (s *Storage) StoreRefreshToken(
 	ctx context.Context,
 	userID string,
-	token string,
+	tokenHash string,
 	expiresAt time.Time,
 ) error {
-	hash, err := jwt.HashToken(token)
-	if err != nil {
-		return errors.Join(storage.ErrTokenProcessing, err)
-	}
-
 	query := `
 		INSERT INTO auth_schema.refresh_tokens 
 		(token_hash, user_id, expires_at) 
 		VALUES ($1, $2, $3)
 		ON CONFLICT (token_hash) DO NOTHING`
 
-	_, err = s.db.Exec(ctx, query, hash, userID, expiresAt)
+	_, err := s.db.Exec(ctx, query, tokenHash, userID, expiresAt)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
@@ -73,13 +67,8 @@ func (s *Storage) GetRefreshToken(ctx context.Context, tokenHash string) (*stora
 	return &rt, nil
 }
 
-func (s *Storage) ValidateRefreshToken(ctx context.Context, token string) (*storage.RefreshToken, error) {
-	hash, err := jwt.HashToken(token)
-	if err != nil {
-		return nil, errors.Join(storage.ErrTokenProcessing, err)
-	}
-
-	rt, err := s.GetRefreshToken(ctx, hash)
+func (s *Storage) ValidateRefreshToken(ctx context.Context, tokenHash string) (*storage.RefreshToken, error) {
+	rt, err := s.GetRefreshToken(ctx, tokenHash)
 	if err != nil {
 		return nil, err
 	}
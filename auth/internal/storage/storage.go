@@ -13,12 +13,25 @@ type RefreshToken struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// AccessToken is a personal access token; only the hash of the token
+// itself is stored.
+type AccessToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Email      string     `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
 var (
 	ErrUserExists   = errors.New("user already exists")
 	ErrUserNotFound = errors.New("user not found")
 )
 var (
-	ErrTokenNotFound   = errors.New("refresh token not found")
-	ErrTokenExpired    = errors.New("refresh token expired")
-	ErrTokenProcessing = errors.New("token processing error")
+	ErrTokenNotFound = errors.New("refresh token not found")
+	ErrTokenExpired  = errors.New("refresh token expired")
 )
+var ErrAccessTokenNotFound = errors.New("access token not found")
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"auth/internal/lib/jwt"
 	"auth/internal/repository"
 	"auth/internal/transport"
 	"errors"
@@ -13,8 +12,9 @@ import (
 )
 
 type AuthHandler struct {
-	log  *zap.SugaredLogger
-	repo transport.Repository
+	log    *zap.SugaredLogger
+	repo   transport.Repository
+	tokens transport.TokenVerifier
 }
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -24,10 +24,11 @@ type TokenResponse struct {
 	RefreshTokenExpiresIn int `json:"refresh_token_expires_in"`
 }
 
-func NewAuthHandler(log *zap.SugaredLogger, authService transport.Repository) *AuthHandler {
+func NewAuthHandler(log *zap.SugaredLogger, authService transport.Repository, tokens transport.TokenVerifier) *AuthHandler {
 	return &AuthHandler{
-		log:  log,
-		repo: authService,
+		log:    log,
+		repo:   authService,
+		tokens: tokens,
 	}
 }
 func (h *AuthHandler) Register() http.HandlerFunc {
@@ -188,7 +189,7 @@ func (h *AuthHandler) DeleteAccount() http.HandlerFunc {
 			return
 		}
 
-		user, err := jwt.VerifyToken(token)
+		user, err := h.tokens.VerifyToken(token)
 		if err != nil {
 			h.log.Warn("invalid refresh token", zap.Error(err))
 			render.Status(r, http.StatusUnauthorized)
@@ -267,3 +268,13 @@ func clearAuthCookies(w http.ResponseWriter) {
 		SameSite: http.SameSiteStrictMode,
 	})
 }
+
+// JWKS publishes the public keys access tokens are signed with, so that
+// other services can verify tokens without calling this one.
+func (h *AuthHandler) JWKS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, h.tokens.PublicKeys())
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"auth/internal/repository"
+	"auth/internal/storage"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CreateTokenResponse struct {
+	Token string `json:"token"`
+	storage.AccessToken
+}
+
+func (h *AuthHandler) CreateToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.sessionUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Name      string     `json:"name"`
+			Scopes    []string   `json:"scopes"`
+			ExpiresAt *time.Time `json:"expires_at"`
+		}
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			h.log.Error("failed to decode create token request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrorResponse{"invalid request format"})
+			return
+		}
+		if req.Name == "" || len(req.Name) > 100 {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrorResponse{"name must contain from 1 to 100 characters"})
+			return
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrorResponse{"expires_at must be in the future"})
+			return
+		}
+
+		token, at, err := h.repo.CreateAccessToken(r.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrInvalidScope):
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, ErrorResponse{"scopes must be links:read or links:write"})
+			case errors.Is(err, repository.ErrInvalidCredentials):
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, ErrorResponse{"user not found"})
+			default:
+				h.log.Error("failed to create access token", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, ErrorResponse{"internal server error"})
+			}
+			return
+		}
+
+		h.log.Infow("access token created", zap.Int64("user_id", userID), zap.Int64("id", at.ID))
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, CreateTokenResponse{Token: token, AccessToken: *at})
+	}
+}
+
+func (h *AuthHandler) ListTokens() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.sessionUserID(w, r)
+		if !ok {
+			return
+		}
+
+		tokens, err := h.repo.ListAccessTokens(r.Context(), userID)
+		if err != nil {
+			h.log.Error("failed to list access tokens", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, ErrorResponse{"internal server error"})
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, map[string][]storage.AccessToken{"tokens": tokens})
+	}
+}
+
+func (h *AuthHandler) RevokeToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := h.sessionUserID(w, r)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrorResponse{"invalid token id"})
+			return
+		}
+
+		if err = h.repo.RevokeAccessToken(r.Context(), userID, id); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrTokenNotFound):
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrorResponse{"token not found"})
+			default:
+				h.log.Error("failed to revoke access token", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, ErrorResponse{"internal server error"})
+			}
+			return
+		}
+
+		h.log.Infow("access token revoked", zap.Int64("user_id", userID), zap.Int64("id", id))
+		render.Status(r, http.StatusNoContent)
+		render.NoContent(w, r)
+	}
+}
+
+// sessionUserID returns the ID of the user logged in with the access_token
+// cookie. Personal access tokens cannot be used to manage tokens.
+func (h *AuthHandler) sessionUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	cookie, err := r.Cookie("access_token")
+	if err != nil {
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, ErrorResponse{"access token required"})
+		return 0, false
+	}
+
+	user, err := h.tokens.VerifyToken(cookie.Value)
+	if err != nil {
+		h.log.Warn("invalid access token", zap.Error(err))
+		render.Status(r, http.StatusUnauthorized)
+		render.JSON(w, r, ErrorResponse{"invalid access token"})
+		return 0, false
+	}
+
+	userID, err := strconv.ParseInt(user.ID, 10, 64)
+	if err != nil {
+		h.log.Errorw("invalid user ID in token", zap.String("id", user.ID))
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, ErrorResponse{"invalid user ID"})
+		return 0, false
+	}
+	return userID, true
+}
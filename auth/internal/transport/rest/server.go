@@ -23,6 +23,7 @@ type Server struct {
 func NewServer(
 	log *zap.SugaredLogger,
 	authService transport.Repository,
+	tokens transport.TokenVerifier,
 	cfg config.HTTPConfig,
 ) *Server {
 	r := chi.NewRouter()
@@ -39,7 +40,11 @@ func NewServer(
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
-	authHandler := handlers.NewAuthHandler(log, authService)
+	authHandler := handlers.NewAuthHandler(log, authService, tokens)
+
+	// URLFormat strips the .json extension before routing, so this serves
+	// /.well-known/jwks.json.
+	r.Get("/.well-known/jwks", authHandler.JWKS())
 
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/register", authHandler.Register())
@@ -47,6 +52,9 @@ func NewServer(
 		r.Get("/refresh", authHandler.Refresh())
 		r.Delete("/logout", authHandler.Logout())
 		r.Delete("/account", authHandler.DeleteAccount())
+		r.Get("/tokens", authHandler.ListTokens())
+		r.Post("/tokens", authHandler.CreateToken())
+		r.Delete("/tokens/{id}", authHandler.RevokeToken())
 	})
 
 	return &Server{
@@ -1,33 +1,71 @@
 package rpc
 
 import (
-	"auth/internal/lib/jwt"
+	"auth/internal/lib/pat"
+	"auth/internal/repository"
 	"auth/internal/transport"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/Killazius/linkify-proto/pkg/api"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"strconv"
+	"strings"
 )
 
+// isAdminHeader carries the user's is_admin flag back to the caller,
+// since TokenResponse has no field for it.
+const isAdminHeader = "x-is-admin"
+
+// scopesHeader carries the comma-separated scopes of a personal access
+// token. It is not sent for JWTs, which grant full access, including the
+// admin routes that tokens never reach.
+const scopesHeader = "x-token-scopes"
+
+// errInvalidUserID means a token that verified carries a user ID that is
+// not a number.
+var errInvalidUserID = errors.New("invalid user id")
+
 type Service struct {
-	repo transport.Repository
+	repo   transport.Repository
+	tokens transport.TokenVerifier
 	api.UnimplementedAuthServer
 }
 
-func New(repo transport.Repository) *Service {
-	return &Service{repo: repo}
+func New(repo transport.Repository, tokens transport.TokenVerifier) *Service {
+	return &Service{repo: repo, tokens: tokens}
 }
 
 func Register(gRPC *grpc.Server, service *Service) {
 	api.RegisterAuthServer(gRPC, service)
 }
 
-func (s *Service) ValidateToken(_ context.Context, req *api.TokenRequest) (*api.TokenResponse, error) {
-	user, err := jwt.VerifyToken(req.Token)
+// ValidateToken accepts both JWTs and personal access tokens, the latter
+// being told apart by their prefix.
+func (s *Service) ValidateToken(ctx context.Context, req *api.TokenRequest) (*api.TokenResponse, error) {
+	if pat.IsToken(req.Token) {
+		return s.validateAccessToken(ctx, req.Token)
+	}
+	user, err := s.tokens.VerifyToken(req.Token)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, "invalid token")
 	}
+	isAdmin, err := s.isAdmin(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, errInvalidUserID) {
+			return nil, status.Error(codes.InvalidArgument, "invalid token")
+		}
+		if errors.Is(err, repository.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to check user role")
+	}
+	if err = grpc.SetHeader(ctx, metadata.Pairs(isAdminHeader, strconv.FormatBool(isAdmin))); err != nil {
+		return nil, status.Error(codes.Internal, "failed to set response header")
+	}
 	return &api.TokenResponse{
 		Valid:  true,
 		UserId: user.ID,
@@ -36,3 +74,42 @@ func (s *Service) ValidateToken(_ context.Context, req *api.TokenRequest) (*api.
 	}, nil
 
 }
+
+func (s *Service) validateAccessToken(ctx context.Context, token string) (*api.TokenResponse, error) {
+	at, err := s.repo.ValidateAccessToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return nil, status.Error(codes.Internal, "failed to validate token")
+	}
+	isAdmin, err := s.repo.IsAdmin(ctx, at.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "failed to check user role")
+	}
+	// The shared proto has no field or method for scopes, so they travel
+	// as a response header; the shortener enforces them per route.
+	header := metadata.Pairs(
+		isAdminHeader, strconv.FormatBool(isAdmin),
+		scopesHeader, strings.Join(at.Scopes, ","),
+	)
+	if err = grpc.SetHeader(ctx, header); err != nil {
+		return nil, status.Error(codes.Internal, "failed to set response header")
+	}
+	return &api.TokenResponse{
+		Valid:  true,
+		UserId: strconv.FormatInt(at.UserID, 10),
+		Email:  at.Email,
+	}, nil
+}
+
+func (s *Service) isAdmin(ctx context.Context, id string) (bool, error) {
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("%w %q", errInvalidUserID, id)
+	}
+	return s.repo.IsAdmin(ctx, userID)
+}
@@ -1,6 +1,12 @@
 package transport
 
-import "context"
+import (
+	"auth/internal/domain"
+	"auth/internal/lib/jwt"
+	"auth/internal/storage"
+	"context"
+	"time"
+)
 
 type Repository interface {
 	Register(ctx context.Context, email, password string) (userID int64, err error)
@@ -9,4 +15,13 @@ type Repository interface {
 	RefreshTokens(ctx context.Context, refreshToken string) (newAccessToken, newRefreshToken string, err error)
 	Logout(ctx context.Context, token string) (err error)
 	DeleteAccount(ctx context.Context, userID int64) error
+	CreateAccessToken(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (token string, at *storage.AccessToken, err error)
+	ListAccessTokens(ctx context.Context, userID int64) ([]storage.AccessToken, error)
+	RevokeAccessToken(ctx context.Context, userID, id int64) error
+	ValidateAccessToken(ctx context.Context, token string) (*storage.AccessToken, error)
+}
+
+type TokenVerifier interface {
+	VerifyToken(token string) (*domain.User, error)
+	PublicKeys() jwt.JWKS
 }
@@ -0,0 +1,13 @@
+CREATE TABLE auth_schema.access_tokens (
+                                id BIGSERIAL PRIMARY KEY,
+                                token_hash TEXT NOT NULL UNIQUE,
+                                user_id BIGINT NOT NULL,
+                                name TEXT NOT NULL,
+                                scopes TEXT[] NOT NULL,
+                                expires_at TIMESTAMP,
+                                last_used_at TIMESTAMP,
+                                created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+                                FOREIGN KEY (user_id) REFERENCES auth_schema.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_tokens_user_id ON auth_schema.access_tokens(user_id);
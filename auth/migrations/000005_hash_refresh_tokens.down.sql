@@ -0,0 +1 @@
+ALTER TABLE auth_schema.refresh_tokens DROP CONSTRAINT IF EXISTS refresh_tokens_token_hash_check;
@@ -0,0 +1,6 @@
+-- Refresh tokens used to be stored as is. They cannot be rehashed here
+-- without the pepper, so they are dropped and users log in again.
+DELETE FROM auth_schema.refresh_tokens;
+
+ALTER TABLE auth_schema.refresh_tokens
+    ADD CONSTRAINT refresh_tokens_token_hash_check CHECK (token_hash ~ '^[0-9a-f]{64}$');
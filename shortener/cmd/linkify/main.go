@@ -4,13 +4,20 @@ import (
 	"context"
 	"go.uber.org/zap"
 	_ "linkify/docs"
+	"linkify/internal/clicks"
 	"linkify/internal/client"
 	"linkify/internal/config"
+	"linkify/internal/lib/geoip"
+	"linkify/internal/lib/jwks"
 	"linkify/internal/metrics"
+	"linkify/internal/screening"
 	"linkify/internal/storage/cache"
 	"linkify/internal/storage/postgresql"
+	"linkify/internal/sweeper"
 	"linkify/internal/transport"
 	"linkify/pkg/logger"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -49,12 +56,44 @@ func main() {
 	if err != nil {
 		log.Fatal("failed to initialize auth client", zap.Error(err))
 	}
-	srv := transport.New(cfg.HTTPServer, log, repo, redisCache, metricsCollector, cc)
+	var keys transport.SigningKeys
+	if cfg.Auth.JWKSURL != "" {
+		keyCache := jwks.New(&http.Client{Timeout: cfg.Auth.JWKSTimeout}, cfg.Auth.JWKSURL, cfg.Auth.JWKSRefreshInterval, log)
+		go keyCache.Run()
+		defer keyCache.Stop(context.Background())
+		keys = keyCache
+	}
+	clickWriter := clicks.New(cfg.Clicks, log, repo)
+	go clickWriter.Run()
+
+	var countries transport.GeoIP
+	if cfg.GeoIP.Database != "" {
+		geoDB, err := geoip.Open(cfg.GeoIP.Database)
+		if err != nil {
+			log.Fatal("failed to open GeoIP database", zap.Error(err))
+		}
+		defer geoDB.Close()
+		countries = geoDB
+	}
+
+	screeningCfg := cfg.Screening
+	if base, err := url.Parse(cfg.HTTPServer.BaseURL()); err == nil && base.Host != "" {
+		screeningCfg.SelfHosts = append(screeningCfg.SelfHosts, base.Hostname())
+	}
+	screener, err := screening.New(screeningCfg, log)
+	if err != nil {
+		log.Fatal("failed to initialize url screening", zap.Error(err))
+	}
+
+	srv := transport.New(cfg.HTTPServer, log, repo, redisCache, metricsCollector, cfg.Auth, cc, keys, clickWriter, countries, screener)
 
 	go srv.MustRun()
 	log.Infow("starting server", "address", cfg.HTTPServer.Address)
 	go metricsCollector.MustRun()
 	log.Infow("starting metricsCollector", "address", cfg.Prometheus.Address)
+	expiredSweeper := sweeper.New(cfg.Sweeper, log, repo)
+	go expiredSweeper.Run()
+	log.Infow("starting sweeper", "interval", cfg.Sweeper.Interval)
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
@@ -63,6 +102,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	expiredSweeper.Stop(ctx)
 	srv.Stop(ctx)
 	metricsCollector.Stop(ctx)
 	log.Info("server shutting down")
@@ -0,0 +1,100 @@
+package clicks
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"linkify/internal/config"
+	"linkify/internal/storage"
+	"time"
+)
+
+type Repository interface {
+	SaveClicks(clicks []storage.Click) error
+}
+
+// Writer buffers click events and inserts them in batches in the
+// background, so recording a click never blocks a redirect.
+type Writer struct {
+	repo   Repository
+	cfg    config.Clicks
+	log    *zap.SugaredLogger
+	events chan storage.Click
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func New(cfg config.Clicks, log *zap.SugaredLogger, repo Repository) *Writer {
+	return &Writer{
+		repo:   repo,
+		cfg:    cfg,
+		log:    log,
+		events: make(chan storage.Click, cfg.BufferSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Track queues a click. When the buffer is full the click is dropped.
+func (w *Writer) Track(click storage.Click) {
+	select {
+	case w.events <- click:
+	default:
+		w.log.Warnw("click buffer is full, dropping click", "alias", click.Alias)
+	}
+}
+
+// Run writes queued clicks whenever a batch fills up or the flush
+// interval passes, until Stop is called.
+func (w *Writer) Run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]storage.Click, 0, w.cfg.BatchSize)
+	for {
+		select {
+		case click := <-w.events:
+			batch = w.add(batch, click)
+		case <-ticker.C:
+			batch = w.flush(batch)
+		case <-w.stop:
+			for {
+				select {
+				case click := <-w.events:
+					batch = w.add(batch, click)
+				default:
+					w.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Writer) add(batch []storage.Click, click storage.Click) []storage.Click {
+	batch = append(batch, click)
+	if len(batch) >= w.cfg.BatchSize {
+		return w.flush(batch)
+	}
+	return batch
+}
+
+func (w *Writer) flush(batch []storage.Click) []storage.Click {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := w.repo.SaveClicks(batch); err != nil {
+		w.log.Errorw("failed to save clicks", "count", len(batch), "error", err)
+	}
+	return batch[:0]
+}
+
+// Stop flushes the queued clicks and waits for Run to return.
+func (w *Writer) Stop(ctx context.Context) {
+	close(w.stop)
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.log.Error("failed to stop click writer", zap.Error(ctx.Err()))
+	}
+}
@@ -0,0 +1,71 @@
+package clicks_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/clicks"
+	"linkify/internal/config"
+	"linkify/internal/storage"
+	"linkify/pkg/logger/zapdiscard"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	mu      sync.Mutex
+	batches [][]storage.Click
+}
+
+func (f *fakeRepo) SaveClicks(clicks []storage.Click) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]storage.Click(nil), clicks...))
+	return nil
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+	repo := &fakeRepo{}
+	w := clicks.New(config.Clicks{
+		BufferSize:    100,
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}, zapdiscard.New(), repo)
+	go w.Run()
+
+	for _, alias := range []string{"a", "b", "c"} {
+		w.Track(storage.Click{Alias: alias})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	w.Stop(ctx)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	require.Len(t, repo.batches, 2)
+	require.Len(t, repo.batches[0], 2)
+	require.Equal(t, "c", repo.batches[1][0].Alias)
+}
+
+func TestWriterDropsWhenFull(t *testing.T) {
+	t.Parallel()
+	repo := &fakeRepo{}
+	w := clicks.New(config.Clicks{
+		BufferSize:    1,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	}, zapdiscard.New(), repo)
+
+	w.Track(storage.Click{Alias: "a"})
+	w.Track(storage.Click{Alias: "b"})
+	go w.Run()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	w.Stop(ctx)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	require.Len(t, repo.batches, 1)
+	require.Equal(t, []storage.Click{{Alias: "a"}}, repo.batches[0])
+}
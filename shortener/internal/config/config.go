@@ -5,6 +5,7 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"log"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,11 @@ type Config struct {
 	HTTPServer HTTPServer `yaml:"http_server"`
 	Redis      Redis      `yaml:"redis"`
 	Prometheus Prometheus `yaml:"prometheus"`
+	Sweeper    Sweeper    `yaml:"sweeper"`
+	Clicks     Clicks     `yaml:"clicks"`
+	GeoIP      GeoIP      `yaml:"geoip"`
+	Screening  Screening  `yaml:"screening"`
+	Auth       Auth       `yaml:"auth"`
 }
 
 type Redis struct {
@@ -23,18 +29,103 @@ type Redis struct {
 }
 
 type HTTPServer struct {
-	Address     string        `yaml:"address" env:"HTTP_ADDRESS" env-default:"8080"`
-	IP          string        `env:"SERVER_IP" env-default:"localhost"`
-	Timeout     time.Duration `yaml:"timeout" env:"HTTP_TIMEOUT" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
-	AliasLength int           `yaml:"alias_length"`
+	Address              string        `yaml:"address" env:"HTTP_ADDRESS" env-default:"8080"`
+	IP                   string        `env:"SERVER_IP" env-default:"localhost"`
+	PublicBaseURL        string        `yaml:"public_base_url" env:"PUBLIC_BASE_URL"`
+	Timeout              time.Duration `yaml:"timeout" env:"HTTP_TIMEOUT" env-default:"4s"`
+	IdleTimeout          time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	AliasLength          int           `yaml:"alias_length"`
+	CustomAliasMinLength int           `yaml:"custom_alias_min_length" env-default:"3"`
+	CustomAliasMaxLength int           `yaml:"custom_alias_max_length" env-default:"32"`
+	ReservedAliases      []string      `yaml:"reserved_aliases" env-default:"api,swagger,auth,metrics"`
+	BatchLimit           int           `yaml:"batch_limit" env-default:"1000"`
+	LinkCookieSecret     string        `env:"LINK_COOKIE_SECRET"`
+	LinkCookieTTL        time.Duration `yaml:"link_cookie_ttl" env-default:"1h"`
+	VariantCookieTTL     time.Duration `yaml:"variant_cookie_ttl" env-default:"720h"`
+	// DefaultRedirectType is the redirect status of links without their own
+	// redirect_type: 301, 302, 307 or 308.
+	DefaultRedirectType int `yaml:"default_redirect_type" env-default:"302"`
+	// PermanentCacheControl is the Cache-Control header of 301 and 308
+	// redirects; empty leaves caching to the browser.
+	PermanentCacheControl string `yaml:"permanent_cache_control" env-default:"no-cache"`
+	// MetaFetchTimeout and MetaFetchMaxBytes bound reading the link card
+	// metadata from a destination page.
+	MetaFetchTimeout  time.Duration `yaml:"meta_fetch_timeout" env-default:"5s"`
+	MetaFetchMaxBytes int64         `yaml:"meta_fetch_max_bytes" env-default:"1048576"`
+	// ReportRateLimit is how many abuse reports a client address may send
+	// per ReportRateWindow.
+	ReportRateLimit  int           `yaml:"report_rate_limit" env-default:"5"`
+	ReportRateWindow time.Duration `yaml:"report_rate_window" env-default:"1h"`
+	// PendingLinkResponse is either PendingLinkPage or PendingLinkNotFound.
+	PendingLinkResponse string `yaml:"pending_link_response" env-default:"page"`
 }
+
+// Responses to a link whose activation window has not opened yet.
+const (
+	PendingLinkPage     = "page"
+	PendingLinkNotFound = "not_found"
+)
+
 type Prometheus struct {
 	Address     string        `yaml:"address" env:"PROMETHEUS_ADDRESS" env-default:"8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 }
 
+// Sweeper configures the background removal of expired links.
+type Sweeper struct {
+	Interval  time.Duration `yaml:"interval" env-default:"1m"`
+	BatchSize int           `yaml:"batch_size" env-default:"500"`
+	Archive   bool          `yaml:"archive" env-default:"false"`
+}
+
+// Clicks configures the buffered writer of click events.
+type Clicks struct {
+	BufferSize    int           `yaml:"buffer_size" env-default:"10000"`
+	BatchSize     int           `yaml:"batch_size" env-default:"500"`
+	FlushInterval time.Duration `yaml:"flush_interval" env-default:"1s"`
+}
+
+// GeoIP configures the country lookup of routing rules. Without a database
+// file rules with a country condition never match.
+type GeoIP struct {
+	Database string `yaml:"database" env:"GEOIP_DATABASE"`
+}
+
+// Screening configures the checks of link destinations. DenyList and
+// AllowList are files with one domain per line; a non-empty allow list
+// accepts only its domains. SelfHosts are the hosts of the service itself,
+// in addition to the host of PublicBaseURL.
+type Screening struct {
+	Schemes      []string `yaml:"schemes" env-default:"http,https"`
+	BlockPrivate bool     `yaml:"block_private" env-default:"true"`
+	DenyList     string   `yaml:"deny_list" env:"SCREENING_DENY_LIST"`
+	AllowList    string   `yaml:"allow_list" env:"SCREENING_ALLOW_LIST"`
+	SelfHosts    []string `yaml:"self_hosts"`
+}
+
+// Auth configures the authentication of API requests. TokenSources lists
+// where the access token is looked for, in order of precedence: "header"
+// for Authorization: Bearer and "cookie" for the access_token cookie.
+// With JWKSURL set, access tokens are verified locally with the keys
+// published there, refetched every JWKSRefreshInterval, and confirmed with
+// the auth service at most once per RecheckInterval.
+type Auth struct {
+	TokenSources        []string      `yaml:"token_sources" env-default:"header,cookie"`
+	JWKSURL             string        `yaml:"jwks_url" env:"AUTH_JWKS_URL"`
+	JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval" env-default:"5m"`
+	JWKSTimeout         time.Duration `yaml:"jwks_timeout" env-default:"5s"`
+	RecheckInterval     time.Duration `yaml:"recheck_interval" env-default:"1m"`
+}
+
+// BaseURL returns the scheme and host short links are served from.
+func (s HTTPServer) BaseURL() string {
+	if s.PublicBaseURL != "" {
+		return strings.TrimSuffix(s.PublicBaseURL, "/")
+	}
+	return "http://" + s.IP
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
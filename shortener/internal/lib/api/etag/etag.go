@@ -0,0 +1,32 @@
+package etag
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid entity tag")
+
+// Format returns the strong entity tag of a link version.
+func Format(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ParseIfMatch returns the version required by an If-Match header.
+// An empty header or "*" matches any version and yields 0.
+func ParseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, ErrInvalid
+	}
+	return version, nil
+}
@@ -0,0 +1,38 @@
+package etag_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/api/etag"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		header  string
+		version int
+		wantErr bool
+	}{
+		{name: "Empty", header: "", version: 0},
+		{name: "Any", header: "*", version: 0},
+		{name: "Version", header: etag.Format(3), version: 3},
+		{name: "Unquoted", header: "3", wantErr: true},
+		{name: "Weak", header: `W/"3"`, wantErr: true},
+		{name: "Not a number", header: `"abc"`, wantErr: true},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			version, err := etag.ParseIfMatch(tc.header)
+			if tc.wantErr {
+				require.ErrorIs(t, err, etag.ErrInvalid)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.version, version)
+		})
+	}
+}
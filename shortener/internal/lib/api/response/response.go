@@ -37,6 +37,16 @@ func ValidateError(errs validator.ValidationErrors) Response {
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is required", err.Field()))
 		case "url":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+		case "allowed_scheme":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s has a scheme that is not allowed", err.Field()))
+		case "public_host":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s points to a private or local address", err.Field()))
+		case "allowed_domain":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s points to a domain that is not allowed", err.Field()))
+		case "not_self":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s points to this service", err.Field()))
+		case "safe_url":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s is reported as malicious", err.Field()))
 		default:
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
 		}
@@ -0,0 +1,33 @@
+// Package clientip finds the address of the client behind the proxy.
+//
+// The service is assumed to be reachable only through a single trusted
+// proxy, nginx in the bundled setup, which appends the address of its peer
+// to X-Forwarded-For. Earlier entries are sent by the client and can be
+// anything, so only the last one is used. Exposing the service directly,
+// or behind more than one proxy, breaks this assumption.
+package clientip
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// FromRequest returns the original client address: the last entry of
+// X-Forwarded-For, or the peer address of the connection without it.
+func FromRequest(r *http.Request) string {
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		last := forwarded[len(forwarded)-1]
+		if i := strings.LastIndex(last, ","); i >= 0 {
+			last = last[i+1:]
+		}
+		if ip := strings.TrimSpace(last); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
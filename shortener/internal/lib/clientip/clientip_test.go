@@ -0,0 +1,66 @@
+package clientip_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"linkify/internal/lib/clientip"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromRequest(t *testing.T) {
+	testCases := []struct {
+		name       string
+		forwarded  []string
+		remoteAddr string
+		want       string
+	}{
+		{
+			name:       "Remote address",
+			remoteAddr: "10.0.0.1:5555",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "Forwarded by the proxy",
+			forwarded:  []string{"203.0.113.7"},
+			remoteAddr: "10.0.0.1:5555",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Client entries are ignored",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7"},
+			remoteAddr: "10.0.0.1:5555",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Last of several headers",
+			forwarded:  []string{"198.51.100.1", "203.0.113.7"},
+			remoteAddr: "10.0.0.1:5555",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Empty last entry",
+			forwarded:  []string{"203.0.113.7, "},
+			remoteAddr: "10.0.0.1:5555",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "Remote address without port",
+			remoteAddr: "10.0.0.1",
+			want:       "10.0.0.1",
+		},
+	}
+	t.Parallel()
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = testCase.remoteAddr
+			for _, forwarded := range testCase.forwarded {
+				r.Header.Add("X-Forwarded-For", forwarded)
+			}
+			assert.Equal(t, testCase.want, clientip.FromRequest(r))
+		})
+	}
+}
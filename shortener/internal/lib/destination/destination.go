@@ -0,0 +1,68 @@
+package destination
+
+import (
+	"fmt"
+	"linkify/internal/storage"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Build returns target with the forwarded visitor parameters and the UTM
+// parameters of the link added to its query.
+//
+// Forwarded parameters replace all values of the same name in target.
+// UTM parameters are only added when neither target nor the visitor set
+// them. The query of target otherwise keeps its order and encoding, and
+// its fragment stays after the query.
+func Build(target string, forwarded url.Values, utm *storage.UTM) (string, error) {
+	const op = "lib.destination.Build"
+	utmParams := utm.Params()
+	if len(forwarded) == 0 && len(utmParams) == 0 {
+		return target, nil
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	present := make(map[string]bool)
+	var pairs []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if _, ok := forwarded[key]; ok {
+			continue
+		}
+		present[key] = true
+		pairs = append(pairs, pair)
+	}
+
+	keys := make([]string, 0, len(forwarded))
+	for key := range forwarded {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		present[key] = true
+		for _, value := range forwarded[key] {
+			pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	for _, param := range utmParams {
+		if !present[param.Key] {
+			pairs = append(pairs, url.QueryEscape(param.Key)+"="+url.QueryEscape(param.Value))
+		}
+	}
+
+	u.RawQuery = strings.Join(pairs, "&")
+	u.ForceQuery = false
+	return u.String(), nil
+}
@@ -0,0 +1,92 @@
+package destination_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/destination"
+	"linkify/internal/storage"
+	"net/url"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	campaign := &storage.UTM{Source: "newsletter", Medium: "email", Campaign: "spring sale"}
+	testCases := []struct {
+		name      string
+		target    string
+		forwarded url.Values
+		utm       *storage.UTM
+		want      string
+	}{
+		{
+			name:   "Nothing to add",
+			target: "https://example.com/path?b=2&a=1#top",
+			want:   "https://example.com/path?b=2&a=1#top",
+		},
+		{
+			name:      "Forwarded to empty query",
+			target:    "https://example.com/path",
+			forwarded: url.Values{"ref": {"tg"}},
+			want:      "https://example.com/path?ref=tg",
+		},
+		{
+			name:      "Destination order kept",
+			target:    "https://example.com/?z=26&a=1",
+			forwarded: url.Values{"m": {"13"}},
+			want:      "https://example.com/?z=26&a=1&m=13",
+		},
+		{
+			name:      "Forwarded replaces duplicate keys",
+			target:    "https://example.com/?id=1&tag=a&tag=b&x=1",
+			forwarded: url.Values{"tag": {"c", "d"}},
+			want:      "https://example.com/?id=1&x=1&tag=c&tag=d",
+		},
+		{
+			name:      "Encoded destination key",
+			target:    "https://example.com/?q%5B%5D=1&q=2",
+			forwarded: url.Values{"q[]": {"3"}},
+			want:      "https://example.com/?q=2&q%5B%5D=3",
+		},
+		{
+			name:      "Fragment stays last",
+			target:    "https://example.com/app#/settings?tab=1",
+			forwarded: url.Values{"lang": {"en"}},
+			utm:       campaign,
+			want:      "https://example.com/app?lang=en&utm_source=newsletter&utm_medium=email&utm_campaign=spring+sale#/settings?tab=1",
+		},
+		{
+			name:   "UTM kept from destination",
+			target: "https://example.com/?utm_source=site",
+			utm:    campaign,
+			want:   "https://example.com/?utm_source=site&utm_medium=email&utm_campaign=spring+sale",
+		},
+		{
+			name:      "UTM kept from visitor",
+			target:    "https://example.com/",
+			forwarded: url.Values{"utm_medium": {"social"}},
+			utm:       campaign,
+			want:      "https://example.com/?utm_medium=social&utm_source=newsletter&utm_campaign=spring+sale",
+		},
+		{
+			name:      "Empty question mark",
+			target:    "https://example.com/?",
+			forwarded: url.Values{"a": {""}},
+			want:      "https://example.com/?a=",
+		},
+		{
+			name:   "Empty UTM",
+			target: "https://example.com/",
+			utm:    &storage.UTM{},
+			want:   "https://example.com/",
+		},
+	}
+	t.Parallel()
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := destination.Build(testCase.target, testCase.forwarded, testCase.utm)
+			require.NoError(t, err)
+			require.Equal(t, testCase.want, got)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package geoip
+
+import (
+	"fmt"
+	"github.com/oschwald/maxminddb-golang"
+	"net"
+)
+
+// DB looks up countries in a local MaxMind GeoLite2/GeoIP2 Country or City
+// database file.
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+func Open(path string) (*DB, error) {
+	const op = "lib.geoip.Open"
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &DB{reader: reader}, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country ip belongs to,
+// or an empty string when the address is invalid or not in the database.
+func (db *DB) Country(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if err := db.reader.Lookup(addr, &record); err != nil {
+		return ""
+	}
+	return record.Country.ISOCode
+}
+
+func (db *DB) Close() error {
+	return db.reader.Close()
+}
@@ -0,0 +1,169 @@
+// Package jwks keeps the public keys of a JSON Web Key Set endpoint.
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxBodySize bounds the JWKS response; a key set is a few kilobytes.
+const maxBodySize = 1 << 20
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+}
+
+// Cache holds the signing keys published by the auth service, refreshing
+// them in the background. A failed refresh keeps the keys fetched before.
+type Cache struct {
+	client   *http.Client
+	url      string
+	interval time.Duration
+	log      *zap.SugaredLogger
+
+	mu   sync.RWMutex
+	keys map[string]crypto.PublicKey
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func New(client *http.Client, url string, interval time.Duration, log *zap.SugaredLogger) *Cache {
+	return &Cache{
+		client:   client,
+		url:      url,
+		interval: interval,
+		log:      log,
+		keys:     map[string]crypto.PublicKey{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Key returns the public key with the given kid.
+func (c *Cache) Key(kid string) (crypto.PublicKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// Run fetches the keys right away and then on every interval until Stop
+// is called.
+func (c *Cache) Run() {
+	defer close(c.done)
+	c.refresh()
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.refresh()
+		}
+	}
+}
+
+func (c *Cache) refresh() {
+	if err := c.Refresh(context.Background()); err != nil {
+		c.log.Errorw("failed to refresh signing keys", "url", c.url, "error", err)
+	}
+}
+
+// Refresh replaces the cached keys with those currently published. Keys of
+// unsupported types are skipped.
+func (c *Cache) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode key set: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			c.log.Warnw("skipping signing key", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cache) Stop(ctx context.Context) {
+	close(c.stop)
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		c.log.Error("failed to stop signing key refresh", zap.Error(ctx.Err()))
+	}
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
@@ -0,0 +1,72 @@
+package jwks_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/jwks"
+	"linkify/pkg/logger/zapdiscard"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheRefresh(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	body := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","use":"sig","alg":"RS256","kid":"rsa","n":%q,"e":%q},
+		{"kty":"OKP","use":"sig","alg":"EdDSA","kid":"ed","crv":"Ed25519","x":%q},
+		{"kty":"EC","use":"sig","kid":"ec","crv":"P-256","x":"AA","y":"AA"},
+		{"kty":"RSA","use":"enc","kid":"enc","n":%q,"e":"AQAB"}
+	]}`,
+		b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		b64(edKey),
+		b64(rsaKey.N.Bytes()),
+	)
+
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	cache := jwks.New(srv.Client(), srv.URL, time.Minute, zapdiscard.New())
+	_, ok := cache.Key("rsa")
+	require.False(t, ok)
+
+	require.NoError(t, cache.Refresh(context.Background()))
+
+	key, ok := cache.Key("rsa")
+	require.True(t, ok)
+	require.True(t, rsaKey.PublicKey.Equal(key))
+
+	key, ok = cache.Key("ed")
+	require.True(t, ok)
+	require.True(t, edKey.Equal(key))
+
+	for _, kid := range []string{"ec", "enc", "missing"} {
+		_, ok = cache.Key(kid)
+		require.False(t, ok, kid)
+	}
+
+	failing.Store(true)
+	require.Error(t, cache.Refresh(context.Background()))
+	_, ok = cache.Key("rsa")
+	require.True(t, ok, "keys are kept after a failed refresh")
+}
@@ -0,0 +1,251 @@
+// Package linkfile reads and writes links in the CSV and JSON Lines formats
+// used by export and import.
+package linkfile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"linkify/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+var ErrUnknownFormat = errors.New("format must be csv or jsonl")
+
+// header lists the CSV columns in the order they are written.
+var header = []string{"alias", "url", "created_at", "expires_at", "clicks"}
+
+// ParseFormat returns the format named by s. An empty s selects CSV.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatJSONL:
+		return FormatJSONL, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type Writer interface {
+	Write(link storage.ExportedLink) error
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+// NewWriter returns a Writer of the format. CSV output starts with a header.
+func NewWriter(w io.Writer, f Format) Writer {
+	if f == FormatJSONL {
+		bw := bufio.NewWriter(w)
+		return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}
+	}
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvWriter) Write(link storage.ExportedLink) error {
+	if !c.headerWritten {
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	expiresAt := ""
+	if link.ExpiresAt != nil {
+		expiresAt = link.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return c.w.Write([]string{
+		link.Alias,
+		link.URL,
+		link.CreatedAt.UTC().Format(time.RFC3339),
+		expiresAt,
+		strconv.FormatInt(link.Clicks, 10),
+	})
+}
+
+func (c *csvWriter) Flush() error {
+	if !c.headerWritten {
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(link storage.ExportedLink) error {
+	return j.enc.Encode(link)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
+
+type Reader interface {
+	// Read returns the next link or io.EOF after the last one. A malformed
+	// record yields a *RecordError, after which reading may continue.
+	Read() (storage.ExportedLink, error)
+	// Line returns the line number of the record last read.
+	Line() int
+}
+
+// RecordError describes a record that could not be parsed.
+type RecordError struct {
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// NewReader returns a Reader of the format. CSV input must start with a
+// header naming at least the url column; columns may come in any order
+// and unknown ones are ignored.
+func NewReader(r io.Reader, f Format) Reader {
+	if f == FormatJSONL {
+		return &jsonlReader{s: bufio.NewScanner(r)}
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return &csvReader{r: cr}
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	line    int
+}
+
+func (c *csvReader) Line() int {
+	return c.line
+}
+
+func (c *csvReader) Read() (storage.ExportedLink, error) {
+	if c.columns == nil {
+		if err := c.readHeader(); err != nil {
+			return storage.ExportedLink{}, err
+		}
+	}
+	record, err := c.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			c.line = parseErr.Line
+			return storage.ExportedLink{}, &RecordError{Line: parseErr.Line, Err: parseErr.Err}
+		}
+		return storage.ExportedLink{}, err
+	}
+	c.line, _ = c.r.FieldPos(0)
+	line := c.line
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	link := storage.ExportedLink{
+		Alias: field("alias"),
+		URL:   field("url"),
+	}
+	if v := field("created_at"); v != "" {
+		if link.CreatedAt, err = time.Parse(time.RFC3339, v); err != nil {
+			return storage.ExportedLink{}, &RecordError{Line: line, Err: errors.New("invalid created_at")}
+		}
+	}
+	if v := field("expires_at"); v != "" {
+		expiresAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return storage.ExportedLink{}, &RecordError{Line: line, Err: errors.New("invalid expires_at")}
+		}
+		link.ExpiresAt = &expiresAt
+	}
+	if v := field("clicks"); v != "" {
+		if link.Clicks, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return storage.ExportedLink{}, &RecordError{Line: line, Err: errors.New("invalid clicks")}
+		}
+	}
+	return link, nil
+}
+
+func (c *csvReader) readHeader() error {
+	record, err := c.r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+		return fmt.Errorf("invalid header: %w", err)
+	}
+	c.columns = make(map[string]int, len(record))
+	for i, name := range record {
+		c.columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := c.columns["url"]; !ok {
+		c.columns = nil
+		return errors.New("invalid header: url column is missing")
+	}
+	return nil
+}
+
+type jsonlReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func (j *jsonlReader) Line() int {
+	return j.line
+}
+
+func (j *jsonlReader) Read() (storage.ExportedLink, error) {
+	for j.s.Scan() {
+		j.line++
+		data := strings.TrimSpace(j.s.Text())
+		if data == "" {
+			continue
+		}
+		var link storage.ExportedLink
+		if err := json.Unmarshal([]byte(data), &link); err != nil {
+			return storage.ExportedLink{}, &RecordError{Line: j.line, Err: errors.New("invalid json")}
+		}
+		return link, nil
+	}
+	if err := j.s.Err(); err != nil {
+		return storage.ExportedLink{}, err
+	}
+	return storage.ExportedLink{}, io.EOF
+}
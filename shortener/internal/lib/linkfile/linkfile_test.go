@@ -0,0 +1,100 @@
+package linkfile_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"linkify/internal/lib/linkfile"
+	"linkify/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	links := []storage.ExportedLink{
+		{
+			Alias:     "promo",
+			URL:       "https://example.com/a?x=1,2",
+			CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			ExpiresAt: &expiresAt,
+			Clicks:    3,
+		},
+		{
+			Alias:     "H2vga5",
+			URL:       `https://example.com/"b"`,
+			CreatedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, format := range []linkfile.Format{linkfile.FormatCSV, linkfile.FormatJSONL} {
+		format := format
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := linkfile.NewWriter(&buf, format)
+			for _, link := range links {
+				require.NoError(t, w.Write(link))
+			}
+			require.NoError(t, w.Flush())
+
+			r := linkfile.NewReader(&buf, format)
+			for _, want := range links {
+				got, err := r.Read()
+				require.NoError(t, err)
+				require.Equal(t, want, got)
+			}
+			_, err := r.Read()
+			require.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	input := "URL,alias\n" +
+		"https://example.com,first\n" +
+		"https://example.com\n"
+	r := linkfile.NewReader(strings.NewReader(input), linkfile.FormatCSV)
+
+	link, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, storage.ExportedLink{Alias: "first", URL: "https://example.com"}, link)
+
+	link, err = r.Read()
+	require.NoError(t, err)
+	require.Equal(t, storage.ExportedLink{URL: "https://example.com"}, link)
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReaderErrors(t *testing.T) {
+	t.Run("Missing url column", func(t *testing.T) {
+		r := linkfile.NewReader(strings.NewReader("alias\nfirst\n"), linkfile.FormatCSV)
+		_, err := r.Read()
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid record", func(t *testing.T) {
+		input := "url,expires_at\nhttps://example.com,tomorrow\nhttps://example.com,\n"
+		r := linkfile.NewReader(strings.NewReader(input), linkfile.FormatCSV)
+		_, err := r.Read()
+		var recordErr *linkfile.RecordError
+		require.True(t, errors.As(err, &recordErr))
+		require.Equal(t, 2, recordErr.Line)
+
+		_, err = r.Read()
+		require.NoError(t, err)
+	})
+
+	t.Run("Invalid json", func(t *testing.T) {
+		input := "{\"url\":\"https://example.com\"}\n\nnot json\n"
+		r := linkfile.NewReader(strings.NewReader(input), linkfile.FormatJSONL)
+		_, err := r.Read()
+		require.NoError(t, err)
+		_, err = r.Read()
+		var recordErr *linkfile.RecordError
+		require.True(t, errors.As(err, &recordErr))
+		require.Equal(t, 3, recordErr.Line)
+	})
+}
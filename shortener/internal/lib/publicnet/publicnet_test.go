@@ -0,0 +1,57 @@
+package publicnet_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/publicnet"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	cases := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.216.34", public: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{addr: "127.0.0.1"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "100.64.0.1"},
+		{addr: "0.0.0.0"},
+		{addr: "::1"},
+		{addr: "fc00::1"},
+		{addr: "fe80::1"},
+		{addr: "::ffff:127.0.0.1"},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.public, publicnet.IsPublic(netip.MustParseAddr(tc.addr)), tc.addr)
+	}
+}
+
+func TestControl(t *testing.T) {
+	cases := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "Public", address: "93.184.216.34:443"},
+		{name: "Loopback", address: "127.0.0.1:8085", wantErr: true},
+		{name: "Private IPv6", address: "[fd00::1]:80", wantErr: true},
+		{name: "Metadata service", address: "169.254.169.254:80", wantErr: true},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := publicnet.Control("tcp", tc.address, nil)
+			if tc.wantErr {
+				require.ErrorIs(t, err, publicnet.ErrNotPublic)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
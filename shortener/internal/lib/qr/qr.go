@@ -0,0 +1,146 @@
+// Package qr renders QR codes as PNG and SVG images.
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/skip2/go-qrcode"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidLevel = errors.New("level must be one of L, M, Q, H")
+	ErrInvalidColor = errors.New("color must be a hex RGB value")
+)
+
+// Options control the rendering of a code. Size is the width and height of
+// the image in pixels and Margin the width of the quiet zone in modules.
+type Options struct {
+	Size       int
+	Level      qrcode.RecoveryLevel
+	Margin     int
+	Foreground color.RGBA
+	Background color.RGBA
+}
+
+// ParseLevel converts an error-correction level letter to its value.
+func ParseLevel(s string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return qrcode.Low, nil
+	case "M":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	}
+	return 0, ErrInvalidLevel
+}
+
+// ParseColor parses a colour written as RRGGBB or RGB, with an optional
+// leading '#'.
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// PNG returns the code of content as a PNG image.
+func PNG(content string, opts Options) ([]byte, error) {
+	modules, err := bitmap(content, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	size, scale, offset := layout(len(modules), opts)
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{opts.Background, opts.Foreground})
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			left, top := offset+x*scale, offset+y*scale
+			for py := top; py < top+scale; py++ {
+				for px := left; px < left+scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err = encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG returns the code of content as an SVG image. Dark modules are drawn
+// as a single path on a unit grid that the viewBox scales to Size.
+func SVG(content string, opts Options) ([]byte, error) {
+	modules, err := bitmap(content, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	total := len(modules) + 2*opts.Margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`, hex(opts.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hex(opts.Foreground))
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+opts.Margin, y+opts.Margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
+
+func bitmap(content string, level qrcode.RecoveryLevel) ([][]bool, error) {
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	return code.Bitmap(), nil
+}
+
+// layout fits the modules and the margin into the requested size. When the
+// size is too small for a single pixel per module, the image grows instead.
+func layout(modules int, opts Options) (size, scale, offset int) {
+	total := modules + 2*opts.Margin
+	scale = opts.Size / total
+	if scale < 1 {
+		scale = 1
+	}
+	size = opts.Size
+	if size < total*scale {
+		size = total * scale
+	}
+	offset = (size-total*scale)/2 + opts.Margin*scale
+	return size, scale, offset
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
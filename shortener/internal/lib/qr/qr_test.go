@@ -0,0 +1,67 @@
+package qr_test
+
+import (
+	"bytes"
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"image/png"
+	"linkify/internal/lib/qr"
+	"strings"
+	"testing"
+)
+
+var options = qr.Options{
+	Size:       200,
+	Level:      qrcode.Medium,
+	Margin:     4,
+	Foreground: color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff},
+	Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+}
+
+func TestPNG(t *testing.T) {
+	data, err := qr.PNG("http://localhost/H2vga5", options)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, options.Size, img.Bounds().Dx())
+	require.Equal(t, options.Size, img.Bounds().Dy())
+
+	// Version 2 has 25 modules, so with the margin every module takes
+	// 200 / 33 = 6 pixels. The top left finder pattern starts after the
+	// centring offset of 1 pixel and the 24 pixel quiet zone.
+	require.Equal(t, options.Background, color.RGBAModel.Convert(img.At(0, 0)))
+	require.Equal(t, options.Background, color.RGBAModel.Convert(img.At(24, 24)))
+	require.Equal(t, options.Foreground, color.RGBAModel.Convert(img.At(25, 25)))
+}
+
+func TestPNGGrowsToFit(t *testing.T) {
+	opts := options
+	opts.Size = 10
+	data, err := qr.PNG("http://localhost/H2vga5", opts)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 33, img.Bounds().Dx())
+}
+
+func TestSVG(t *testing.T) {
+	data, err := qr.SVG("http://localhost/H2vga5", options)
+	require.NoError(t, err)
+
+	svg := string(data)
+	require.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="200" height="200" viewBox="0 0 33 33"`))
+	require.Contains(t, svg, `fill="#112233"`)
+	require.Contains(t, svg, "M4 4h1v1h-1z")
+}
+
+func TestParseColor(t *testing.T) {
+	c, err := qr.ParseColor("#0a0")
+	require.NoError(t, err)
+	require.Equal(t, color.RGBA{R: 0x00, G: 0xaa, B: 0x00, A: 0xff}, c)
+
+	_, err = qr.ParseColor("red")
+	require.ErrorIs(t, err, qr.ErrInvalidColor)
+}
@@ -0,0 +1,147 @@
+package targeting
+
+import (
+	"linkify/internal/lib/clientip"
+	"linkify/internal/storage"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operating systems recognized in the User-Agent header.
+const (
+	OSiOS     = "ios"
+	OSAndroid = "android"
+	OSWindows = "windows"
+	OSMacOS   = "macos"
+	OSLinux   = "linux"
+)
+
+// Device classes recognized in the User-Agent header.
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// CountryResolver maps an IP address to an ISO 3166-1 alpha-2 country code,
+// returning an empty string when the address is unknown.
+type CountryResolver interface {
+	Country(ip string) string
+}
+
+// Visitor holds the request attributes routing rules are matched against.
+// Empty fields never match a rule condition.
+type Visitor struct {
+	OS       string
+	Device   string
+	Language string
+	Country  string
+}
+
+// FromRequest describes the visitor behind r. Countries may be nil, then
+// the country is left empty.
+func FromRequest(r *http.Request, countries CountryResolver) Visitor {
+	os, device := ParseUserAgent(r.UserAgent())
+	v := Visitor{
+		OS:       os,
+		Device:   device,
+		Language: PreferredLanguage(r.Header.Get("Accept-Language")),
+	}
+	if countries != nil {
+		v.Country = countries.Country(clientip.FromRequest(r))
+	}
+	return v
+}
+
+// Match returns the URL of the first rule whose conditions all hold for v.
+func Match(rules []storage.Rule, v Visitor) (string, bool) {
+	for _, rule := range rules {
+		if matches(rule, v) {
+			return rule.URL, true
+		}
+	}
+	return "", false
+}
+
+func matches(rule storage.Rule, v Visitor) bool {
+	if rule.OS != "" && rule.OS != v.OS {
+		return false
+	}
+	if rule.Device != "" && rule.Device != v.Device {
+		return false
+	}
+	if rule.Country != "" && !strings.EqualFold(rule.Country, v.Country) {
+		return false
+	}
+	if rule.Language != "" && !languageMatches(rule.Language, v.Language) {
+		return false
+	}
+	return true
+}
+
+// languageMatches reports whether tag is the rule language or one of its
+// subtags, so "en" matches "en-GB" but "en-GB" does not match "en".
+func languageMatches(rule, tag string) bool {
+	rule, tag = strings.ToLower(rule), strings.ToLower(tag)
+	return tag == rule || strings.HasPrefix(tag, rule+"-")
+}
+
+// ParseUserAgent detects the operating system and device class of a
+// User-Agent header. Unknown values are returned as empty strings.
+func ParseUserAgent(ua string) (os, device string) {
+	lower := strings.ToLower(ua)
+	switch {
+	case strings.Contains(lower, "iphone"), strings.Contains(lower, "ipod"):
+		return OSiOS, DeviceMobile
+	case strings.Contains(lower, "ipad"):
+		return OSiOS, DeviceTablet
+	case strings.Contains(lower, "android"):
+		// Android tablets omit the "Mobile" token.
+		if strings.Contains(lower, "mobile") {
+			return OSAndroid, DeviceMobile
+		}
+		return OSAndroid, DeviceTablet
+	case strings.Contains(lower, "windows"):
+		return OSWindows, DeviceDesktop
+	case strings.Contains(lower, "macintosh"), strings.Contains(lower, "mac os x"):
+		return OSMacOS, DeviceDesktop
+	case strings.Contains(lower, "linux"), strings.Contains(lower, "x11"):
+		return OSLinux, DeviceDesktop
+	}
+	return "", ""
+}
+
+// PreferredLanguage returns the language tag with the highest weight in an
+// Accept-Language header, or an empty string when there is none.
+func PreferredLanguage(header string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			langs = append(langs, weighted{tag: tag, q: q})
+		}
+	}
+	if len(langs) == 0 {
+		return ""
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+	return langs[0].tag
+}
@@ -0,0 +1,118 @@
+package targeting_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/targeting"
+	"linkify/internal/storage"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	iPhoneUA    = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	iPadUA      = "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	pixelUA     = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+	galaxyTabUA = "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	windowsUA   = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	macUA       = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15"
+	linuxUA     = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	testCases := []struct {
+		name   string
+		ua     string
+		os     string
+		device string
+	}{
+		{name: "iPhone", ua: iPhoneUA, os: targeting.OSiOS, device: targeting.DeviceMobile},
+		{name: "iPad", ua: iPadUA, os: targeting.OSiOS, device: targeting.DeviceTablet},
+		{name: "Android phone", ua: pixelUA, os: targeting.OSAndroid, device: targeting.DeviceMobile},
+		{name: "Android tablet", ua: galaxyTabUA, os: targeting.OSAndroid, device: targeting.DeviceTablet},
+		{name: "Windows", ua: windowsUA, os: targeting.OSWindows, device: targeting.DeviceDesktop},
+		{name: "macOS", ua: macUA, os: targeting.OSMacOS, device: targeting.DeviceDesktop},
+		{name: "Linux", ua: linuxUA, os: targeting.OSLinux, device: targeting.DeviceDesktop},
+		{name: "Unknown", ua: "curl/8.4.0"},
+	}
+	t.Parallel()
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			os, device := targeting.ParseUserAgent(testCase.ua)
+			require.Equal(t, testCase.os, os)
+			require.Equal(t, testCase.device, device)
+		})
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "Empty", header: "", want: ""},
+		{name: "Single", header: "de-AT", want: "de-AT"},
+		{name: "Order", header: "fr-CH, fr;q=0.9, en;q=0.8", want: "fr-CH"},
+		{name: "Weights", header: "en;q=0.5, ru;q=0.9, *;q=1", want: "ru"},
+		{name: "Rejected", header: "es;q=0", want: ""},
+		{name: "Invalid weight", header: "es;q=abc, it;q=0.3", want: "it"},
+	}
+	t.Parallel()
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, testCase.want, targeting.PreferredLanguage(testCase.header))
+		})
+	}
+}
+
+type countries map[string]string
+
+func (c countries) Country(ip string) string {
+	return c[ip]
+}
+
+func TestMatch(t *testing.T) {
+	rules := []storage.Rule{
+		{OS: targeting.OSiOS, URL: "https://apps.apple.com/app"},
+		{OS: targeting.OSAndroid, URL: "https://play.google.com/store/apps"},
+		{Device: targeting.DeviceDesktop, Country: "DE", URL: "https://example.de"},
+		{Language: "pt-BR", URL: "https://example.com/pt-br"},
+		{Language: "pt", URL: "https://example.com/pt"},
+	}
+	testCases := []struct {
+		name     string
+		ua       string
+		language string
+		ip       string
+		want     string
+	}{
+		{name: "iOS", ua: iPadUA, want: "https://apps.apple.com/app"},
+		{name: "Android", ua: pixelUA, language: "de", ip: "203.0.113.5", want: "https://play.google.com/store/apps"},
+		{name: "Country", ua: windowsUA, ip: "203.0.113.5", want: "https://example.de"},
+		{name: "Country on mobile", ua: "Mobile", ip: "203.0.113.5"},
+		{name: "Exact language", ua: linuxUA, language: "pt-BR,pt;q=0.8", want: "https://example.com/pt-br"},
+		{name: "Language subtag", ua: linuxUA, language: "pt-PT", want: "https://example.com/pt"},
+		{name: "No match", ua: macUA, language: "en-US", ip: "198.51.100.1"},
+	}
+	t.Parallel()
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest("GET", "/alias", nil)
+			req.Header.Set("User-Agent", testCase.ua)
+			req.Header.Set("Accept-Language", testCase.language)
+			if testCase.ip != "" {
+				req.RemoteAddr = testCase.ip + ":5555"
+			}
+
+			url, ok := targeting.Match(rules, targeting.FromRequest(req, countries{"203.0.113.5": "DE"}))
+			require.Equal(t, testCase.want != "", ok)
+			require.Equal(t, testCase.want, url)
+		})
+	}
+}
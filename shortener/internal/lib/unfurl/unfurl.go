@@ -0,0 +1,195 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/net/html"
+	"io"
+	"linkify/internal/lib/publicnet"
+	"linkify/internal/storage"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxRedirects bounds the redirects followed by a fetch.
+const maxRedirects = 5
+
+// crawlers are lowercase User-Agent fragments of link preview bots.
+var crawlers = []string{
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"slackbot",
+	"telegrambot",
+	"whatsapp",
+	"discordbot",
+	"linkedinbot",
+	"skypeuripreview",
+	"vkshare",
+	"redditbot",
+	"pinterest",
+	"embedly",
+	"iframely",
+	"mastodon",
+	"snapchat",
+	"viber",
+}
+
+// IsCrawler reports whether ua belongs to a bot that fetches links to
+// show a preview card.
+func IsCrawler(ua string) bool {
+	ua = strings.ToLower(ua)
+	for _, crawler := range crawlers {
+		if strings.Contains(ua, crawler) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNotHTML is returned when the fetched page is not an HTML document.
+var ErrNotHTML = errors.New("page is not HTML")
+
+// Fetcher reads OpenGraph and Twitter card metadata of web pages.
+type Fetcher struct {
+	client  *http.Client
+	maxBody int64
+}
+
+// NewClient returns the client pages are fetched with. The URL comes from
+// users, so the client connects only to public addresses, checked after
+// name resolution, ignores proxy settings and follows at most a few
+// redirects to http and https URLs.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: publicnet.Control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// NewFetcher reads at most maxBody bytes of every page with client, whose
+// timeout bounds a single fetch.
+func NewFetcher(client *http.Client, maxBody int64) *Fetcher {
+	return &Fetcher{client: client, maxBody: maxBody}
+}
+
+// Fetch returns the title, description and image of the page at pageURL.
+// OpenGraph tags take precedence over Twitter card tags, which take
+// precedence over the plain title and description. A relative image URL
+// is resolved against the final page URL.
+func (f *Fetcher) Fetch(ctx context.Context, pageURL string) (*storage.Meta, error) {
+	const op = "lib.unfurl.Fetch"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "LinkifyBot/1.0 (link preview)")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %d", op, resp.StatusCode)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "text/html" {
+		return nil, fmt.Errorf("%s: %w", op, ErrNotHTML)
+	}
+
+	meta, err := parse(io.LimitReader(resp.Body, f.maxBody))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if meta.Image != "" {
+		if image, err := resp.Request.URL.Parse(meta.Image); err == nil {
+			meta.Image = image.String()
+		}
+	}
+	return meta, nil
+}
+
+// parse collects the metadata from the head of an HTML document.
+func parse(r io.Reader) (*storage.Meta, error) {
+	tags := make(map[string]string)
+	var title strings.Builder
+	inTitle := false
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return collect(tags, title.String()), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "meta":
+				var key, content string
+				for _, attr := range token.Attr {
+					switch strings.ToLower(attr.Key) {
+					case "property", "name":
+						key = strings.ToLower(attr.Val)
+					case "content":
+						content = strings.TrimSpace(attr.Val)
+					}
+				}
+				if _, ok := tags[key]; key != "" && !ok {
+					tags[key] = content
+				}
+			case "title":
+				inTitle = true
+			case "body":
+				// Metadata lives in the head, the rest is not worth reading.
+				return collect(tags, title.String()), nil
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+func collect(tags map[string]string, title string) *storage.Meta {
+	first := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return &storage.Meta{
+		Title:       first(tags["og:title"], tags["twitter:title"], strings.TrimSpace(title)),
+		Description: first(tags["og:description"], tags["twitter:description"], tags["description"]),
+		Image:       first(tags["og:image"], tags["og:image:url"], tags["twitter:image"]),
+	}
+}
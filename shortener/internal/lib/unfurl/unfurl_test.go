@@ -0,0 +1,151 @@
+package unfurl_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/publicnet"
+	"linkify/internal/lib/unfurl"
+	"linkify/internal/storage"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsCrawler(t *testing.T) {
+	cases := []struct {
+		ua      string
+		crawler bool
+	}{
+		{ua: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", crawler: true},
+		{ua: "Twitterbot/1.0", crawler: true},
+		{ua: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", crawler: true},
+		{ua: "TelegramBot (like TwitterBot)", crawler: true},
+		{ua: "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", crawler: true},
+		{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"},
+		{ua: ""},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.crawler, unfurl.IsCrawler(tc.ua), tc.ua)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		status      int
+		body        string
+		meta        *storage.Meta
+		err         error
+	}{
+		{
+			name:        "OpenGraph",
+			contentType: "text/html; charset=utf-8",
+			status:      http.StatusOK,
+			body: `<html><head><title>Plain</title>
+<meta name="twitter:title" content="Twitter">
+<meta property="og:title" content="OpenGraph">
+<meta name="description" content="Plain description">
+<meta property="og:image" content="https://cdn.example.com/cover.png">
+</head><body><meta property="og:description" content="ignored"></body></html>`,
+			meta: &storage.Meta{
+				Title:       "OpenGraph",
+				Description: "Plain description",
+				Image:       "https://cdn.example.com/cover.png",
+			},
+		},
+		{
+			name:        "Twitter card",
+			contentType: "text/html",
+			status:      http.StatusOK,
+			body: `<head><title>Plain</title>
+<meta name="twitter:title" content="Twitter">
+<meta name="twitter:description" content="Card">
+<meta name="twitter:image" content="/images/card.png"></head>`,
+			meta: &storage.Meta{Title: "Twitter", Description: "Card", Image: "{server}/images/card.png"},
+		},
+		{
+			name:        "Plain title",
+			contentType: "text/html",
+			status:      http.StatusOK,
+			body:        "<head><title>\n  Spring sale\n</title></head>",
+			meta:        &storage.Meta{Title: "Spring sale"},
+		},
+		{
+			name:        "Not HTML",
+			contentType: "application/json",
+			status:      http.StatusOK,
+			body:        `{"title": "json"}`,
+			err:         unfurl.ErrNotHTML,
+		},
+		{
+			name:        "Error status",
+			contentType: "text/html",
+			status:      http.StatusNotFound,
+			body:        "<title>Not found</title>",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			fetcher := unfurl.NewFetcher(server.Client(), 1<<20)
+			meta, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+			if tc.meta == nil {
+				require.Error(t, err)
+				if tc.err != nil {
+					require.True(t, errors.Is(err, tc.err))
+				}
+				return
+			}
+			require.NoError(t, err)
+			expected := *tc.meta
+			if len(expected.Image) > 0 && expected.Image[0] == '{' {
+				expected.Image = server.URL + expected.Image[len("{server}"):]
+			}
+			require.Equal(t, &expected, meta)
+		})
+	}
+}
+
+func TestFetchReadsAtMostMaxBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<head><meta property="og:title" content="Early">` +
+			`<meta property="og:description" content="Too far"></head>`))
+	}))
+	defer server.Close()
+
+	fetcher := unfurl.NewFetcher(server.Client(), int64(len(`<head><meta property="og:title" content="Early">`)))
+	meta, err := fetcher.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, &storage.Meta{Title: "Early"}, meta)
+}
+
+func TestNewClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<title>Internal</title>"))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	fetcher := unfurl.NewFetcher(unfurl.NewClient(time.Second), 1<<20)
+	for _, pageURL := range []string{server.URL, "http://localhost:" + port} {
+		_, err := fetcher.Fetch(context.Background(), pageURL)
+		require.ErrorIs(t, err, publicnet.ErrNotPublic, pageURL)
+	}
+}
@@ -17,6 +17,8 @@ type metrics struct {
 	linksRedirected     prometheus.Gauge
 	linksDeleted        prometheus.Gauge
 	httpRequestDuration *prometheus.HistogramVec
+	batchSize           *prometheus.HistogramVec
+	variantsServed      *prometheus.CounterVec
 }
 type Collector struct {
 	reg *prometheus.Registry
@@ -46,6 +48,15 @@ func New(cfg config.Prometheus, log *zap.SugaredLogger) *Collector {
 				Help:    "Duration of HTTP requests",
 				Buckets: []float64{0.1, 0.3, 0.5, 1, 3, 5},
 			}, []string{"method", "path", "status"}),
+			batchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Name:    "url_shortener_batch_size",
+				Help:    "Number of items in batch requests",
+				Buckets: []float64{1, 10, 50, 100, 500, 1000, 5000},
+			}, []string{"operation"}),
+			variantsServed: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "url_shortener_variant_redirects_total",
+				Help: "Number of redirects of A/B split links by variant",
+			}, []string{"variant"}),
 		},
 		reg: prometheus.NewRegistry(),
 		cfg: cfg,
@@ -59,6 +70,8 @@ func (c *Collector) Register() {
 		c.linksDeleted,
 		c.httpRequestDuration,
 		c.linksRedirected,
+		c.batchSize,
+		c.variantsServed,
 		collectors.NewGoCollector(),
 	)
 }
@@ -78,6 +91,18 @@ func (c *Collector) ObserveHTTPRequestDuration(method, path, status string, dura
 	c.httpRequestDuration.WithLabelValues(method, path, status).Observe(duration)
 }
 
+// ObserveBatchSize records the number of items of a batch operation.
+func (c *Collector) ObserveBatchSize(operation string, size int) {
+	c.batchSize.WithLabelValues(operation).Observe(float64(size))
+}
+
+// IncVariantServed counts a redirect of an A/B split link to variant. The
+// alias is left out to keep the number of series bounded; the clicks of each
+// link record the variant served for its stats.
+func (c *Collector) IncVariantServed(variant string) {
+	c.variantsServed.WithLabelValues(variant).Inc()
+}
+
 func (c *Collector) MustRun() {
 	if err := c.Run(); err != nil {
 		c.log.Error("failed to run collector", zap.Error(err))
@@ -0,0 +1,238 @@
+package screening
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"linkify/internal/config"
+	"linkify/internal/lib/publicnet"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Validation tags of the checks. A link destination is screened by listing
+// them after "url" in its validate tag; response.ValidateError explains
+// every one of them.
+const (
+	TagScheme     = "allowed_scheme"
+	TagPublicHost = "public_host"
+	TagDomain     = "allowed_domain"
+	TagNotSelf    = "not_self"
+	TagReputation = "safe_url"
+)
+
+// Provider is an external URL reputation service.
+type Provider interface {
+	// IsMalicious reports whether rawURL is known to serve phishing,
+	// malware or other abuse.
+	IsMalicious(ctx context.Context, rawURL string) (bool, error)
+}
+
+// Screener rejects link destinations that must not be shortened.
+type Screener struct {
+	log          *zap.SugaredLogger
+	schemes      map[string]bool
+	blockPrivate bool
+	allow        []string
+	deny         []string
+	selfHosts    map[string]bool
+	providers    []Provider
+}
+
+// New loads the domain lists of cfg. Without configured schemes only http
+// and https are allowed. Providers are asked in order; one that fails is
+// logged and skipped, so an outage of a provider does not block links.
+func New(cfg config.Screening, log *zap.SugaredLogger, providers ...Provider) (*Screener, error) {
+	const op = "screening.New"
+	s := &Screener{
+		log:          log,
+		schemes:      make(map[string]bool),
+		blockPrivate: cfg.BlockPrivate,
+		selfHosts:    make(map[string]bool),
+		providers:    providers,
+	}
+	schemes := cfg.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http", "https"}
+	}
+	for _, scheme := range schemes {
+		s.schemes[strings.ToLower(scheme)] = true
+	}
+	for _, host := range cfg.SelfHosts {
+		s.selfHosts[normalizeHost(host)] = true
+	}
+	var err error
+	if s.allow, err = loadDomains(cfg.AllowList); err != nil {
+		return nil, fmt.Errorf("%s: allow list: %w", op, err)
+	}
+	if s.deny, err = loadDomains(cfg.DenyList); err != nil {
+		return nil, fmt.Errorf("%s: deny list: %w", op, err)
+	}
+	return s, nil
+}
+
+// Register adds the checks to v under their tags.
+func (s *Screener) Register(v *validator.Validate) {
+	register := func(tag string, check func(ctx context.Context, u *url.URL) bool) {
+		// The tags are constant, so registering can only fail on a bug.
+		if err := v.RegisterValidationCtx(tag, func(ctx context.Context, fl validator.FieldLevel) bool {
+			u, err := url.Parse(fl.Field().String())
+			if err != nil {
+				// Malformed URLs are reported by the url tag.
+				return true
+			}
+			return check(ctx, u)
+		}); err != nil {
+			panic(err)
+		}
+	}
+	register(TagScheme, s.checkScheme)
+	register(TagPublicHost, s.checkPublicHost)
+	register(TagDomain, s.checkDomain)
+	register(TagNotSelf, s.checkNotSelf)
+	register(TagReputation, s.checkReputation)
+}
+
+func (s *Screener) checkScheme(_ context.Context, u *url.URL) bool {
+	return s.schemes[strings.ToLower(u.Scheme)]
+}
+
+// checkPublicHost rejects localhost names and IP literals outside the
+// public address space, including the numeric forms browsers accept such
+// as http://2130706433/ and http://0177.0.0.1/.
+func (s *Screener) checkPublicHost(_ context.Context, u *url.URL) bool {
+	if !s.blockPrivate {
+		return true
+	}
+	host := normalizeHost(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	addr, ok := parseIP(host)
+	if !ok {
+		return true
+	}
+	return publicnet.IsPublic(addr)
+}
+
+// checkDomain accepts only domains of the allow list, when it is not
+// empty, and rejects domains of the deny list. Entries match subdomains.
+func (s *Screener) checkDomain(_ context.Context, u *url.URL) bool {
+	host := normalizeHost(u.Hostname())
+	if len(s.allow) > 0 && !matchDomain(host, s.allow) {
+		return false
+	}
+	return !matchDomain(host, s.deny)
+}
+
+// checkNotSelf rejects links to the service itself, which would redirect
+// to another short link or loop.
+func (s *Screener) checkNotSelf(_ context.Context, u *url.URL) bool {
+	return !s.selfHosts[normalizeHost(u.Hostname())]
+}
+
+func (s *Screener) checkReputation(ctx context.Context, u *url.URL) bool {
+	rawURL := u.String()
+	for _, provider := range s.providers {
+		malicious, err := provider.IsMalicious(ctx, rawURL)
+		if err != nil {
+			s.log.Warnw("url reputation check failed", "url", rawURL, "error", err)
+			continue
+		}
+		if malicious {
+			s.log.Infow("url reported as malicious", "url", rawURL)
+			return false
+		}
+	}
+	return true
+}
+
+// loadDomains reads a domain list with one domain per line. Empty lines
+// and lines starting with # are skipped. An empty path gives no domains.
+func loadDomains(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "*.")
+		domains = append(domains, normalizeHost(strings.TrimPrefix(line, ".")))
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// parseIP parses an IP literal, accepting the IPv4 forms of inet_aton:
+// one to four parts in decimal, octal (leading 0) or hex (leading 0x),
+// the last part filling the remaining bytes.
+func parseIP(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr, true
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+	var ip uint64
+	for i, part := range parts {
+		value, err := parseIPv4Part(part)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		if i < len(parts)-1 {
+			if value > 0xff {
+				return netip.Addr{}, false
+			}
+			ip = ip<<8 | value
+			continue
+		}
+		rest := uint(4 - i)
+		if value >= 1<<(8*rest) {
+			return netip.Addr{}, false
+		}
+		ip = ip<<(8*rest) | value
+	}
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+func parseIPv4Part(part string) (uint64, error) {
+	base := 10
+	switch {
+	case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+		part, base = part[2:], 16
+	case len(part) > 1 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+	return strconv.ParseUint(part, base, 32)
+}
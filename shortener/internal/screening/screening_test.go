@@ -0,0 +1,126 @@
+package screening_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/config"
+	"linkify/internal/screening"
+	"linkify/pkg/logger/zapdiscard"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type link struct {
+	URL string `validate:"required,url,allowed_scheme,public_host,allowed_domain,not_self,safe_url"`
+}
+
+type provider struct {
+	malicious map[string]bool
+	err       error
+}
+
+func (p provider) IsMalicious(_ context.Context, rawURL string) (bool, error) {
+	return p.malicious[rawURL], p.err
+}
+
+func writeList(t *testing.T, lines string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	require.NoError(t, os.WriteFile(path, []byte(lines), 0o600))
+	return path
+}
+
+// screen returns the failed tag for rawURL, or an empty string.
+func screen(t *testing.T, screener *screening.Screener, rawURL string) string {
+	t.Helper()
+	validate := validator.New()
+	screener.Register(validate)
+	err := validate.StructCtx(context.Background(), link{URL: rawURL})
+	if err == nil {
+		return ""
+	}
+	var validateErrs validator.ValidationErrors
+	require.True(t, errors.As(err, &validateErrs))
+	return validateErrs[0].Tag()
+}
+
+func TestScreener(t *testing.T) {
+	deny := writeList(t, "# phishing\nevil.example\n\n*.bad.example\n")
+	screener, err := screening.New(config.Screening{
+		BlockPrivate: true,
+		DenyList:     deny,
+		SelfHosts:    []string{"lnk.example"},
+	}, zapdiscard.New(), provider{malicious: map[string]bool{"https://phish.example/login": true}})
+	require.NoError(t, err)
+
+	cases := []struct {
+		url string
+		tag string
+	}{
+		{url: "https://example.com/page"},
+		{url: "HTTP://example.com"},
+		{url: "http://8.8.8.8/"},
+		{url: "http://[2001:4860:4860::8888]/"},
+		{url: "javascript:alert(1)", tag: screening.TagScheme},
+		{url: "data:text/html,hi", tag: screening.TagScheme},
+		{url: "ftp://example.com/file", tag: screening.TagScheme},
+		{url: "http://localhost:8080/", tag: screening.TagPublicHost},
+		{url: "http://api.localhost/", tag: screening.TagPublicHost},
+		{url: "http://127.0.0.1/", tag: screening.TagPublicHost},
+		{url: "http://10.1.2.3/", tag: screening.TagPublicHost},
+		{url: "http://172.16.0.1/", tag: screening.TagPublicHost},
+		{url: "http://169.254.169.254/latest/meta-data", tag: screening.TagPublicHost},
+		{url: "http://100.64.0.1/", tag: screening.TagPublicHost},
+		{url: "http://0.0.0.0/", tag: screening.TagPublicHost},
+		{url: "http://[::1]/", tag: screening.TagPublicHost},
+		{url: "http://[fd00::1]/", tag: screening.TagPublicHost},
+		{url: "http://[::ffff:127.0.0.1]/", tag: screening.TagPublicHost},
+		{url: "http://2130706433/", tag: screening.TagPublicHost},
+		{url: "http://0x7f000001/", tag: screening.TagPublicHost},
+		{url: "http://0177.0.0.1/", tag: screening.TagPublicHost},
+		{url: "http://10.1/", tag: screening.TagPublicHost},
+		{url: "https://evil.example/", tag: screening.TagDomain},
+		{url: "https://login.evil.example/", tag: screening.TagDomain},
+		{url: "https://www.bad.example./", tag: screening.TagDomain},
+		{url: "https://notevil.example/"},
+		{url: "https://lnk.example/abc", tag: screening.TagNotSelf},
+		{url: "https://LNK.example:443/abc", tag: screening.TagNotSelf},
+		{url: "https://phish.example/login", tag: screening.TagReputation},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.tag, screen(t, screener, tc.url), tc.url)
+	}
+}
+
+func TestScreenerAllowList(t *testing.T) {
+	allow := writeList(t, "example.com\n")
+	screener, err := screening.New(config.Screening{AllowList: allow}, zapdiscard.New())
+	require.NoError(t, err)
+
+	require.Empty(t, screen(t, screener, "https://example.com/"))
+	require.Empty(t, screen(t, screener, "https://docs.example.com/"))
+	require.Equal(t, screening.TagDomain, screen(t, screener, "https://example.org/"))
+}
+
+func TestScreenerSchemes(t *testing.T) {
+	screener, err := screening.New(config.Screening{Schemes: []string{"https"}}, zapdiscard.New())
+	require.NoError(t, err)
+
+	require.Empty(t, screen(t, screener, "https://example.com/"))
+	require.Equal(t, screening.TagScheme, screen(t, screener, "http://example.com/"))
+}
+
+func TestScreenerProviderFailure(t *testing.T) {
+	screener, err := screening.New(config.Screening{}, zapdiscard.New(), provider{err: errors.New("timeout")})
+	require.NoError(t, err)
+
+	require.Empty(t, screen(t, screener, "https://example.com/"))
+}
+
+func TestNewMissingList(t *testing.T) {
+	_, err := screening.New(config.Screening{DenyList: filepath.Join(t.TempDir(), "missing.txt")}, zapdiscard.New())
+	require.Error(t, err)
+}
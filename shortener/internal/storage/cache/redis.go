@@ -2,12 +2,16 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"linkify/internal/storage"
 	"time"
 )
 
+// DefaultTTL is how long a link stays cached after it was last read.
+const DefaultTTL = time.Hour
+
 type Storage struct {
 	client *redis.Client
 }
@@ -25,8 +29,15 @@ func New(addr, password string, db int) (*Storage, error) {
 	return &Storage{client: client}, nil
 }
 
-func (s *Storage) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+// Set caches the link for expiration, but never past the link's own expiry
+// or the end of its activation window.
+// Single-use links are never cached.
+func (s *Storage) Set(ctx context.Context, key string, link storage.Link, expiration time.Duration) error {
 	const op = "storage.cache.Set"
+	expiration = capTTL(link, expiration, time.Now())
+	if expiration <= 0 || link.MaxClicks == 1 {
+		return nil
+	}
 	exists, err := s.client.Exists(ctx, key).Result()
 	if err != nil {
 		return fmt.Errorf("%s: failed to check key existence: %w", op, err)
@@ -34,6 +45,10 @@ func (s *Storage) Set(ctx context.Context, key string, value string, expiration
 	if exists > 0 {
 		return fmt.Errorf("%s: %w", op, storage.ErrAliasExists)
 	}
+	value, err := json.Marshal(link)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	err = s.client.Set(ctx, key, value, expiration).Err()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
@@ -41,24 +56,28 @@ func (s *Storage) Set(ctx context.Context, key string, value string, expiration
 	return nil
 }
 
-func (s *Storage) Get(ctx context.Context, key string) (string, error) {
+func (s *Storage) Get(ctx context.Context, key string) (*storage.Link, error) {
 	const op = "storage.cache.Get"
 	exists, err := s.client.Exists(ctx, key).Result()
 	if err != nil {
-		return "", fmt.Errorf("%s: failed to check key existence: %w", op, err)
+		return nil, fmt.Errorf("%s: failed to check key existence: %w", op, err)
 	}
 	if exists == 0 {
-		return "", fmt.Errorf("%s: %w", op, storage.ErrAliasNotFound)
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrAliasNotFound)
 	}
-	res, err := s.client.Get(ctx, key).Result()
+	res, err := s.client.Get(ctx, key).Bytes()
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	err = s.client.Expire(ctx, key, 1*time.Hour).Err()
+	var link storage.Link
+	if err = json.Unmarshal(res, &link); err != nil {
+		return nil, fmt.Errorf("%s: failed to decode cached link: %w", op, err)
+	}
+	err = s.client.Expire(ctx, key, capTTL(link, DefaultTTL, time.Now())).Err()
 	if err != nil {
-		return "", fmt.Errorf("%s: failed to extend key expiration: %w", op, err)
+		return nil, fmt.Errorf("%s: failed to extend key expiration: %w", op, err)
 	}
-	return res, nil
+	return &link, nil
 }
 func (s *Storage) Delete(ctx context.Context, key string) error {
 	const op = "storage.cache.Delete"
@@ -68,6 +87,21 @@ func (s *Storage) Delete(ctx context.Context, key string) error {
 	}
 	return nil
 }
+
+// Allow counts a hit of key in a fixed window and reports whether at most
+// limit hits happened in the current window.
+func (s *Storage) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	const op = "storage.cache.Allow"
+	key = "ratelimit:" + key
+	pipe := s.client.TxPipeline()
+	hits := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return hits.Val() <= int64(limit), nil
+}
+
 func (s *Storage) Stop() error {
 	if s.client != nil {
 		err := s.client.Close()
@@ -77,3 +111,15 @@ func (s *Storage) Stop() error {
 	}
 	return nil
 }
+
+// capTTL keeps a link cached no longer than it may be followed: until it
+// expires or its activation window closes.
+func capTTL(link storage.Link, ttl time.Duration, now time.Time) time.Duration {
+	if link.ExpiresAt != nil {
+		ttl = min(ttl, link.ExpiresAt.Sub(now))
+	}
+	if link.ActiveUntil != nil {
+		ttl = min(ttl, link.ActiveUntil.Sub(now))
+	}
+	return ttl
+}
@@ -0,0 +1,120 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"linkify/internal/storage"
+)
+
+// batchInsertSize bounds the number of rows in a single INSERT statement.
+const batchInsertSize = 500
+
+// SaveBatch inserts the links in a single transaction. The returned slice
+// holds the result of every link in input order: nil when it was saved or
+// ErrAliasExists when its alias is taken, either in the table or by an
+// earlier link of the batch.
+func (s *Storage) SaveBatch(links []storage.Link) ([]error, error) {
+	const op = "storage.postgresql.SaveBatch"
+	const maxAttempts = 3
+
+	var (
+		results []error
+		err     error
+	)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		results, err = s.saveBatch(links)
+		// A concurrent insert may take an alias between the lookup and the
+		// insert; the next attempt sees it in the lookup.
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return results, nil
+}
+
+func (s *Storage) saveBatch(links []storage.Link) ([]error, error) {
+	results := make([]error, len(links))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		aliases := make([]string, len(links))
+		for i, link := range links {
+			aliases[i] = link.Alias
+		}
+		var existing []string
+		if err := tx.Model(&URL{}).Where("alias IN ?", aliases).Pluck("alias", &existing).Error; err != nil {
+			return err
+		}
+		taken := make(map[string]struct{}, len(existing)+len(links))
+		for _, alias := range existing {
+			taken[alias] = struct{}{}
+		}
+
+		urls := make([]URL, 0, len(links))
+		for i, link := range links {
+			if _, ok := taken[link.Alias]; ok {
+				results[i] = storage.ErrAliasExists
+				continue
+			}
+			taken[link.Alias] = struct{}{}
+			urls = append(urls, newURL(link))
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(urls, batchInsertSize).Error
+	})
+	return results, err
+}
+
+// DeleteBatch removes the aliases and their clicks in a single transaction.
+// Aliases that do not exist or that ownerID does not own are left untouched
+// and reported as ErrURLNotFound or ErrForbidden in the returned slice,
+// which follows the input order. Admins may delete any alias.
+func (s *Storage) DeleteBatch(aliases []string, ownerID string, isAdmin bool) ([]error, error) {
+	const op = "storage.postgresql.DeleteBatch"
+	results := make([]error, len(aliases))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var urls []URL
+		if err := tx.Select("id", "alias", "owner_id").Where("alias IN ?", aliases).Find(&urls).Error; err != nil {
+			return err
+		}
+		found := make(map[string]URL, len(urls))
+		for _, url := range urls {
+			found[url.Alias] = url
+		}
+
+		var (
+			ids       []uint
+			deletable []string
+		)
+		for i, alias := range aliases {
+			url, ok := found[alias]
+			switch {
+			case !ok:
+				results[i] = storage.ErrURLNotFound
+				continue
+			case !isAdmin && url.OwnerID != ownerID:
+				results[i] = storage.ErrForbidden
+				continue
+			}
+			// A repeated alias is reported as deleted only once.
+			delete(found, alias)
+			ids = append(ids, url.ID)
+			deletable = append(deletable, alias)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Where("alias IN ?", deletable).Delete(&Click{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&URL{}, ids).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return results, nil
+}
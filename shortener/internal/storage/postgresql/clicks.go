@@ -0,0 +1,109 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"linkify/internal/storage"
+	"time"
+)
+
+const topReferrersLimit = 10
+
+type Click struct {
+	ID        uint      `gorm:"primaryKey"`
+	Alias     string    `gorm:"not null;index:idx_clicks_alias_clicked_at,priority:1"`
+	ClickedAt time.Time `gorm:"not null;index:idx_clicks_alias_clicked_at,priority:2"`
+	Referrer  string    `gorm:"not null;default:''"`
+	UserAgent string    `gorm:"not null;default:''"`
+	IP        string    `gorm:"not null;default:''"`
+	RequestID string    `gorm:"not null;default:''"`
+	Variant   string    `gorm:"not null;default:''"`
+}
+
+// SaveClicks inserts a batch of clicks in a single statement.
+func (s *Storage) SaveClicks(clicks []storage.Click) error {
+	const op = "storage.postgresql.SaveClicks"
+	if len(clicks) == 0 {
+		return nil
+	}
+	rows := make([]Click, 0, len(clicks))
+	for _, c := range clicks {
+		rows = append(rows, Click{
+			Alias:     c.Alias,
+			ClickedAt: c.ClickedAt,
+			Referrer:  c.Referrer,
+			UserAgent: c.UserAgent,
+			IP:        c.IP,
+			RequestID: c.RequestID,
+			Variant:   c.Variant,
+		})
+	}
+	if err := s.db.Create(&rows).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ClickStats aggregates the clicks of alias. Only its owner or an admin may read them.
+func (s *Storage) ClickStats(alias string, ownerID string, isAdmin bool, filter storage.StatsFilter) (*storage.ClickStats, error) {
+	const op = "storage.postgresql.ClickStats"
+	var url URL
+	result := s.db.Select("owner_id").Where("alias = ?", alias).First(&url)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, storage.ErrURLNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, result.Error)
+	}
+	if !isAdmin && url.OwnerID != ownerID {
+		return nil, storage.ErrForbidden
+	}
+
+	clicks := func() *gorm.DB {
+		query := s.db.Model(&Click{}).Where("alias = ?", alias)
+		if filter.From != nil {
+			query = query.Where("clicked_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			query = query.Where("clicked_at < ?", *filter.To)
+		}
+		return query
+	}
+
+	stats := &storage.ClickStats{
+		Series:       []storage.StatsBucket{},
+		TopReferrers: []storage.ReferrerCount{},
+	}
+	if err := clicks().Count(&stats.Total).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	err := clicks().
+		Select("date_trunc(?, clicked_at) AS time, count(*) AS count", filter.Bucket).
+		Group("1").
+		Order("1").
+		Scan(&stats.Series).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	err = clicks().
+		Select("referrer, count(*) AS count").
+		Where("referrer <> ''").
+		Group("referrer").
+		Order("count DESC, referrer").
+		Limit(topReferrersLimit).
+		Scan(&stats.TopReferrers).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	err = clicks().
+		Select("variant, count(*) AS count").
+		Where("variant <> ''").
+		Group("variant").
+		Order("variant").
+		Scan(&stats.Variants).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return stats, nil
+}
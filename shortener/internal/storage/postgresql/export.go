@@ -0,0 +1,38 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"linkify/internal/storage"
+)
+
+// ExportByOwner calls fn for every link of the owner in creation order.
+// Rows are read from a cursor one by one, so memory use does not depend on
+// the number of links. An error returned by fn stops the export.
+func (s *Storage) ExportByOwner(ctx context.Context, ownerID string, fn func(link storage.ExportedLink) error) error {
+	const op = "storage.postgresql.ExportByOwner"
+	rows, err := s.db.WithContext(ctx).Raw(`
+		SELECT u.alias, u.url, u.created_at, u.expires_at,
+			(SELECT COUNT(*) FROM clicks c WHERE c.alias = u.alias) AS clicks
+		FROM urls u
+		WHERE u.owner_id = ?
+		ORDER BY u.id`, ownerID).Rows()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link storage.ExportedLink
+		if err = rows.Scan(&link.Alias, &link.URL, &link.CreatedAt, &link.ExpiresAt, &link.Clicks); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err = fn(link); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
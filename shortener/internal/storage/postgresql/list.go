@@ -0,0 +1,103 @@
+package postgresql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"linkify/internal/storage"
+	"strings"
+	"time"
+)
+
+type cursor struct {
+	SortBy    string    `json:"s"`
+	Alias     string    `json:"a,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+	ID        uint      `json:"i"`
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListByOwner returns a page of the owner's links using keyset pagination
+// on (sort column, id), so pages stay stable while links are added.
+func (s *Storage) ListByOwner(filter storage.ListFilter) (*storage.LinkPage, error) {
+	const op = "storage.postgresql.ListByOwner"
+	column := storage.SortByCreatedAt
+	if filter.SortBy == storage.SortByAlias {
+		column = storage.SortByAlias
+	}
+
+	query := s.db.Model(&URL{}).Where("owner_id = ?", filter.OwnerID)
+	if filter.Query != "" {
+		like := "%" + likeEscaper.Replace(filter.Query) + "%"
+		query = query.Where("(url ILIKE ? OR alias ILIKE ?)", like, like)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil || c.SortBy != column {
+			return nil, storage.ErrInvalidCursor
+		}
+		var value any = c.CreatedAt
+		if column == storage.SortByAlias {
+			value = c.Alias
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison), value, c.ID)
+	}
+
+	var urls []URL
+	err := query.
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(filter.Limit + 1).
+		Find(&urls).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := &storage.LinkPage{Total: total, Links: make([]storage.Link, 0, len(urls))}
+	if len(urls) > filter.Limit {
+		urls = urls[:filter.Limit]
+		last := urls[len(urls)-1]
+		page.NextCursor = encodeCursor(cursor{
+			SortBy:    column,
+			Alias:     last.Alias,
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+		})
+	}
+	for _, url := range urls {
+		page.Links = append(page.Links, url.toLink())
+	}
+	return page, nil
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(raw, &c)
+	return c, err
+}
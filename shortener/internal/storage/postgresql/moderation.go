@@ -0,0 +1,164 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"linkify/internal/storage"
+	"strconv"
+	"time"
+)
+
+type Report struct {
+	ID         int64     `gorm:"primaryKey"`
+	Alias      string    `gorm:"not null;index"`
+	Reason     string    `gorm:"not null"`
+	Comment    string    `gorm:"not null;default:''"`
+	ReporterIP string    `gorm:"not null;default:''"`
+	CreatedAt  time.Time `gorm:"not null;default:now()"`
+}
+
+// Ban is a banned owner; the table is named banned_owners.
+type Ban struct {
+	OwnerID   string    `gorm:"primaryKey"`
+	Reason    string    `gorm:"not null;default:''"`
+	BannedBy  string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func (Ban) TableName() string {
+	return "banned_owners"
+}
+
+// SaveReport stores a report of an existing alias.
+func (s *Storage) SaveReport(report storage.Report) error {
+	const op = "storage.postgresql.SaveReport"
+	var count int64
+	if err := s.db.Model(&URL{}).Where("alias = ?", report.Alias).Count(&count).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if count == 0 {
+		return storage.ErrURLNotFound
+	}
+	row := Report{
+		Alias:      report.Alias,
+		Reason:     report.Reason,
+		Comment:    report.Comment,
+		ReporterIP: report.ReporterIP,
+		CreatedAt:  report.CreatedAt,
+	}
+	if err := s.db.Create(&row).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListReports returns a page of reports, newest first. The cursor is the
+// ID of the last report of the previous page.
+func (s *Storage) ListReports(filter storage.ReportFilter) (*storage.ReportPage, error) {
+	const op = "storage.postgresql.ListReports"
+	query := s.db.Model(&Report{})
+	if filter.Alias != "" {
+		query = query.Where("alias = ?", filter.Alias)
+	}
+	if filter.Cursor != "" {
+		id, err := strconv.ParseInt(filter.Cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, storage.ErrInvalidCursor
+		}
+		query = query.Where("id < ?", id)
+	}
+
+	var rows []Report
+	if err := query.Order("id DESC").Limit(filter.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	page := &storage.ReportPage{Reports: make([]storage.Report, 0, len(rows))}
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		page.NextCursor = strconv.FormatInt(rows[len(rows)-1].ID, 10)
+	}
+	for _, row := range rows {
+		page.Reports = append(page.Reports, storage.Report{
+			ID:         row.ID,
+			Alias:      row.Alias,
+			Reason:     row.Reason,
+			Comment:    row.Comment,
+			ReporterIP: row.ReporterIP,
+			CreatedAt:  row.CreatedAt,
+		})
+	}
+	return page, nil
+}
+
+// BanOwner bans the owner, or updates the existing ban, and disables all
+// of the owner's links. It returns the aliases that were disabled so they
+// can be evicted from the cache.
+func (s *Storage) BanOwner(ban storage.Ban) ([]string, error) {
+	const op = "storage.postgresql.BanOwner"
+	var aliases []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		row := Ban{
+			OwnerID:   ban.OwnerID,
+			Reason:    ban.Reason,
+			BannedBy:  ban.BannedBy,
+			CreatedAt: ban.CreatedAt,
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "owner_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reason", "banned_by", "created_at"}),
+		}).Create(&row).Error
+		if err != nil {
+			return err
+		}
+		var urls []URL
+		err = tx.Model(&urls).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "alias"}}}).
+			Where("owner_id = ? AND NOT disabled", ban.OwnerID).
+			Updates(map[string]any{
+				"disabled":   true,
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": ban.CreatedAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			aliases = append(aliases, url.Alias)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return aliases, nil
+}
+
+// UnbanOwner lifts the ban of the owner. The links disabled by the ban
+// stay disabled until they are enabled one by one.
+func (s *Storage) UnbanOwner(ownerID string) error {
+	const op = "storage.postgresql.UnbanOwner"
+	result := s.db.Where("owner_id = ?", ownerID).Delete(&Ban{})
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return storage.ErrBanNotFound
+	}
+	return nil
+}
+
+// IsBanned reports whether the owner is banned.
+func (s *Storage) IsBanned(ownerID string) (bool, error) {
+	const op = "storage.postgresql.IsBanned"
+	var ban Ban
+	err := s.db.Select("owner_id").Where("owner_id = ?", ownerID).First(&ban).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return true, nil
+}
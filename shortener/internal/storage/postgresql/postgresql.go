@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"linkify/internal/storage"
 	"time"
 )
@@ -15,15 +16,107 @@ type Storage struct {
 	conn *sql.DB
 }
 type URL struct {
-	ID        uint      `gorm:"primaryKey"`
-	Alias     string    `gorm:"unique;not null"`
-	URL       string    `gorm:"not null"`
-	CreatedAt time.Time `gorm:"not null;default:now()"`
+	ID        uint       `gorm:"primaryKey"`
+	Alias     string     `gorm:"unique;not null"`
+	URL       string     `gorm:"not null"`
+	OwnerID   string     `gorm:"index;not null;default:''"`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+	UpdatedAt time.Time  `gorm:"not null;default:now()"`
+	ExpiresAt *time.Time `gorm:"index"`
+	Version   int        `gorm:"not null;default:1"`
+	// PasswordHash is the bcrypt hash of the link password, empty for
+	// links without one.
+	PasswordHash []byte
+	MaxClicks    int `gorm:"not null;default:0"`
+	// ClicksLeft is NULL for links without a click limit.
+	ClicksLeft     *int
+	ActiveFrom     *time.Time
+	ActiveUntil    *time.Time
+	Rules          []storage.Rule    `gorm:"serializer:json;type:jsonb"`
+	Variants       []storage.Variant `gorm:"serializer:json;type:jsonb"`
+	StickyVariants bool              `gorm:"not null;default:false"`
+	ForwardQuery   bool              `gorm:"not null;default:false"`
+	UTM            *storage.UTM      `gorm:"serializer:json;type:jsonb"`
+	RedirectType   int               `gorm:"not null;default:0"`
+	Title          string            `gorm:"not null;default:''"`
+	Interstitial   bool              `gorm:"not null;default:false"`
+	Meta           *storage.Meta     `gorm:"serializer:json;type:jsonb"`
+	Disabled       bool              `gorm:"not null;default:false"`
+}
+
+// ArchivedURL keeps expired links removed by the sweeper when archiving is enabled.
+type ArchivedURL struct {
+	ID         uint      `gorm:"primaryKey"`
+	Alias      string    `gorm:"index;not null"`
+	URL        string    `gorm:"not null"`
+	OwnerID    string    `gorm:"index;not null;default:''"`
+	CreatedAt  time.Time `gorm:"not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	ArchivedAt time.Time `gorm:"not null;default:now()"`
+}
+
+func newURL(link storage.Link) URL {
+	url := URL{
+		Alias:          link.Alias,
+		URL:            link.URL,
+		OwnerID:        link.OwnerID,
+		CreatedAt:      link.CreatedAt,
+		UpdatedAt:      link.CreatedAt,
+		ExpiresAt:      link.ExpiresAt,
+		Version:        1,
+		PasswordHash:   link.PasswordHash,
+		MaxClicks:      link.MaxClicks,
+		ActiveFrom:     link.ActiveFrom,
+		ActiveUntil:    link.ActiveUntil,
+		Rules:          link.Rules,
+		Variants:       link.Variants,
+		StickyVariants: link.StickyVariants,
+		ForwardQuery:   link.ForwardQuery,
+		UTM:            link.UTM,
+		RedirectType:   link.RedirectType,
+		Title:          link.Title,
+		Interstitial:   link.Interstitial,
+		Meta:           link.Meta,
+		Disabled:       link.Disabled,
+	}
+	if link.IsLimited() {
+		clicksLeft := link.MaxClicks
+		url.ClicksLeft = &clicksLeft
+	}
+	return url
+}
+
+func (u URL) toLink() storage.Link {
+	return storage.Link{
+		Alias:          u.Alias,
+		URL:            u.URL,
+		OwnerID:        u.OwnerID,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		ExpiresAt:      u.ExpiresAt,
+		Version:        u.Version,
+		Protected:      len(u.PasswordHash) > 0,
+		PasswordHash:   u.PasswordHash,
+		MaxClicks:      u.MaxClicks,
+		ClicksLeft:     u.ClicksLeft,
+		ActiveFrom:     u.ActiveFrom,
+		ActiveUntil:    u.ActiveUntil,
+		Rules:          u.Rules,
+		Variants:       u.Variants,
+		StickyVariants: u.StickyVariants,
+		ForwardQuery:   u.ForwardQuery,
+		UTM:            u.UTM,
+		RedirectType:   u.RedirectType,
+		Title:          u.Title,
+		Interstitial:   u.Interstitial,
+		Meta:           u.Meta,
+		Disabled:       u.Disabled,
+	}
 }
 
 func New(url string) (*Storage, error) {
 	const op = "storage.postgresql.New"
-	db, err := gorm.Open(postgres.Open(url), &gorm.Config{}) //sql.Open("pgx", url)
+	db, err := gorm.Open(postgres.Open(url), &gorm.Config{TranslateError: true}) //sql.Open("pgx", url)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -35,20 +128,16 @@ func New(url string) (*Storage, error) {
 	conn.SetMaxIdleConns(25)
 	conn.SetConnMaxLifetime(5 * time.Minute)
 
-	err = db.AutoMigrate(&URL{})
+	err = db.AutoMigrate(&URL{}, &ArchivedURL{}, &Click{}, &Report{}, &Ban{})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return &Storage{db: db, conn: conn}, nil
 }
 
-func (s *Storage) Save(urlToSave string, alias string, createdAt time.Time) error {
+func (s *Storage) Save(link storage.Link) error {
 	const op = "storage.postgresql.Save"
-	url := URL{
-		Alias:     alias,
-		URL:       urlToSave,
-		CreatedAt: createdAt,
-	}
+	url := newURL(link)
 	result := s.db.Create(&url)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
@@ -59,31 +148,184 @@ func (s *Storage) Save(urlToSave string, alias string, createdAt time.Time) erro
 	return nil
 }
 
-func (s *Storage) Get(alias string) (string, error) {
+func (s *Storage) Get(alias string) (*storage.Link, error) {
 	const op = "storage.postgresql.Get"
 	var url URL
 	result := s.db.Where("alias = ?", alias).First(&url)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return "", storage.ErrURLNotFound
+			return nil, storage.ErrURLNotFound
 		}
-		return "", fmt.Errorf("%s: %w", op, result.Error)
+		return nil, fmt.Errorf("%s: %w", op, result.Error)
 	}
-	return url.URL, nil
+	link := url.toLink()
+	return &link, nil
 }
 
-func (s *Storage) Delete(alias string) error {
-	const op = "storage.postgresql.Delete"
-	result := s.db.Where("alias = ?", alias).Delete(&URL{})
+// ConsumeClick atomically takes one click from a limited link and returns
+// the link as it is after the click. ErrLinkExhausted is returned once no
+// clicks are left, so concurrent visitors never exceed the limit.
+func (s *Storage) ConsumeClick(alias string) (*storage.Link, error) {
+	const op = "storage.postgresql.ConsumeClick"
+	var url URL
+	result := s.db.Model(&url).
+		Clauses(clause.Returning{}).
+		Where("alias = ? AND clicks_left > 0", alias).
+		UpdateColumn("clicks_left", gorm.Expr("clicks_left - 1"))
 	if result.Error != nil {
-		return fmt.Errorf("%s: %w", op, result.Error)
+		return nil, fmt.Errorf("%s: %w", op, result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return storage.ErrURLNotFound
+		if _, err := s.Get(alias); err != nil {
+			return nil, err
+		}
+		return nil, storage.ErrLinkExhausted
+	}
+	link := url.toLink()
+	return &link, nil
+}
+
+// Delete removes the alias and its clicks if ownerID owns it. Admins may delete any alias.
+func (s *Storage) Delete(alias string, ownerID string, isAdmin bool) error {
+	const op = "storage.postgresql.Delete"
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var url URL
+		result := tx.Select("id", "owner_id").Where("alias = ?", alias).First(&url)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return storage.ErrURLNotFound
+			}
+			return result.Error
+		}
+		if !isAdmin && url.OwnerID != ownerID {
+			return storage.ErrForbidden
+		}
+		if err := tx.Where("alias = ?", alias).Delete(&Click{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&URL{}, url.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) || errors.Is(err, storage.ErrForbidden) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
+// Update applies the changes to the alias if ownerID owns it or isAdmin is set.
+// A non-zero version must match the stored one, otherwise ErrVersionConflict
+// is returned. Every successful update increments the version.
+func (s *Storage) Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error) {
+	const op = "storage.postgresql.Update"
+	var url URL
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("alias = ?", alias).First(&url)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return storage.ErrURLNotFound
+			}
+			return result.Error
+		}
+		if !isAdmin && url.OwnerID != ownerID {
+			return storage.ErrForbidden
+		}
+		if version != 0 && url.Version != version {
+			return storage.ErrVersionConflict
+		}
+		if update.URL != nil {
+			url.URL = *update.URL
+		}
+		if update.ClearExpiry {
+			url.ExpiresAt = nil
+		} else if update.ExpiresAt != nil {
+			url.ExpiresAt = update.ExpiresAt
+		}
+		if update.Reschedule {
+			url.ActiveFrom = update.ActiveFrom
+			url.ActiveUntil = update.ActiveUntil
+		}
+		if update.SetRules {
+			url.Rules = update.Rules
+		}
+		if update.SetVariants {
+			url.Variants = update.Variants
+			url.StickyVariants = update.StickyVariants
+		}
+		if update.ForwardQuery != nil {
+			url.ForwardQuery = *update.ForwardQuery
+		}
+		if update.UTM != nil {
+			url.UTM = update.UTM
+			if len(update.UTM.Params()) == 0 {
+				url.UTM = nil
+			}
+		}
+		if update.RedirectType != nil {
+			url.RedirectType = *update.RedirectType
+		}
+		if update.Title != nil {
+			url.Title = *update.Title
+		}
+		if update.Interstitial != nil {
+			url.Interstitial = *update.Interstitial
+		}
+		if update.Meta != nil {
+			url.Meta = update.Meta
+			if update.Meta.IsZero() {
+				url.Meta = nil
+			}
+		}
+		if update.Disabled != nil {
+			url.Disabled = *update.Disabled
+		}
+		url.Version++
+		return tx.Save(&url).Error
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) ||
+			errors.Is(err, storage.ErrForbidden) ||
+			errors.Is(err, storage.ErrVersionConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	link := url.toLink()
+	return &link, nil
+}
+
+// DeleteExpired removes up to batchSize links that expired before now,
+// together with their clicks, and returns how many links were removed. With
+// archive set, the removed rows are copied to archived_urls in the same
+// statement.
+func (s *Storage) DeleteExpired(now time.Time, batchSize int, archive bool) (int64, error) {
+	const op = "storage.postgresql.DeleteExpired"
+	query := `
+		WITH expired AS (
+			DELETE FROM urls WHERE id IN (
+				SELECT id FROM urls WHERE expires_at <= @now ORDER BY id LIMIT @batch FOR UPDATE SKIP LOCKED
+			)
+			RETURNING alias, url, owner_id, created_at, expires_at
+		), removed_clicks AS (
+			DELETE FROM clicks WHERE alias IN (SELECT alias FROM expired)
+		)`
+	if archive {
+		query += `, archived AS (
+			INSERT INTO archived_urls (alias, url, owner_id, created_at, expires_at, archived_at)
+			SELECT alias, url, owner_id, created_at, expires_at, @now FROM expired
+		)`
+	}
+	query += ` SELECT count(*) FROM expired`
+
+	var removed int64
+	err := s.db.Raw(query, sql.Named("now", now), sql.Named("batch", batchSize)).Scan(&removed).Error
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return removed, nil
+}
+
 func (s *Storage) Stop() error {
 	if s.conn != nil {
 		err := s.conn.Close()
@@ -1,9 +1,314 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrAliasExists   = errors.New("alias already exists")
-	ErrURLNotFound   = errors.New("URL not found")
-	ErrAliasNotFound = errors.New("alias not found")
+	ErrAliasExists     = errors.New("alias already exists")
+	ErrURLNotFound     = errors.New("URL not found")
+	ErrAliasNotFound   = errors.New("alias not found")
+	ErrForbidden       = errors.New("access denied")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrVersionConflict = errors.New("link was modified concurrently")
+	ErrLinkExhausted   = errors.New("link click limit reached")
+	ErrBanNotFound     = errors.New("owner is not banned")
+)
+
+// Link is a shortened URL as passed between the storage and transport layers.
+// PasswordHash is never serialized, so a protected link read from the cache
+// only carries Protected and has to be loaded from the storage to check
+// the password.
+type Link struct {
+	Alias        string     `json:"alias"`
+	URL          string     `json:"url"`
+	OwnerID      string     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Version      int        `json:"version"`
+	Protected    bool       `json:"protected,omitempty"`
+	PasswordHash []byte     `json:"-"`
+	// MaxClicks limits how many times the link may be followed, zero means
+	// no limit. ClicksLeft is only set for limited links and is not kept
+	// up to date in the cache.
+	MaxClicks  int  `json:"max_clicks,omitempty"`
+	ClicksLeft *int `json:"clicks_left,omitempty"`
+	// ActiveFrom and ActiveUntil bound the window in which the link
+	// redirects; nil leaves the window open on that side.
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	// Rules are checked in order before redirecting to URL.
+	Rules []Rule `json:"rules,omitempty"`
+	// Variants split the traffic not sent elsewhere by Rules across several
+	// destinations by weight. With StickyVariants a visitor keeps getting
+	// the variant chosen on the first visit.
+	Variants       []Variant `json:"variants,omitempty"`
+	StickyVariants bool      `json:"sticky_variants,omitempty"`
+	// ForwardQuery passes the query string of the short link on to the
+	// destination; UTM parameters are added to it on every redirect.
+	ForwardQuery bool `json:"forward_query,omitempty"`
+	UTM          *UTM `json:"utm,omitempty"`
+	// RedirectType is the HTTP status of the redirect, zero means the
+	// service default.
+	RedirectType int `json:"redirect_type,omitempty"`
+	// Title is shown on the preview page, which Interstitial links show
+	// before every redirect.
+	Title        string `json:"title,omitempty"`
+	Interstitial bool   `json:"interstitial,omitempty"`
+	// Meta is served to link preview bots instead of the redirect.
+	Meta *Meta `json:"meta,omitempty"`
+	// Disabled links were taken down by an admin and no longer redirect.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Meta is the OpenGraph and Twitter card metadata of a link.
+type Meta struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+}
+
+// IsZero reports whether m has no metadata.
+func (m *Meta) IsZero() bool {
+	return m == nil || *m == Meta{}
+}
+
+// IsRedirectType reports whether status may be used as the RedirectType
+// of a link.
+func IsRedirectType(status int) bool {
+	switch status {
+	case 301, 302, 307, 308:
+		return true
+	}
+	return false
+}
+
+// UTM holds the campaign parameters added to the destination of a link.
+type UTM struct {
+	Source   string `json:"utm_source,omitempty"`
+	Medium   string `json:"utm_medium,omitempty"`
+	Campaign string `json:"utm_campaign,omitempty"`
+	Term     string `json:"utm_term,omitempty"`
+	Content  string `json:"utm_content,omitempty"`
+}
+
+// Param is a single query parameter.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params returns the non-empty parameters in their conventional order.
+// A nil UTM has none.
+func (u *UTM) Params() []Param {
+	if u == nil {
+		return nil
+	}
+	var params []Param
+	for _, param := range []Param{
+		{Key: "utm_source", Value: u.Source},
+		{Key: "utm_medium", Value: u.Medium},
+		{Key: "utm_campaign", Value: u.Campaign},
+		{Key: "utm_term", Value: u.Term},
+		{Key: "utm_content", Value: u.Content},
+	} {
+		if param.Value != "" {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// Variant is one of the weighted destinations of an A/B split link.
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// Rule sends visitors matching all of its non-empty conditions to URL
+// instead of the default destination of the link.
+type Rule struct {
+	OS       string `json:"os,omitempty"`
+	Device   string `json:"device,omitempty"`
+	Language string `json:"language,omitempty"`
+	Country  string `json:"country,omitempty"`
+	URL      string `json:"url"`
+}
+
+// LinkUpdate lists the attributes to change; nil fields are left as is.
+// ClearExpiry removes the expiration date and takes precedence over ExpiresAt.
+// With Reschedule set, ActiveFrom and ActiveUntil replace the activation
+// window, nil values included. With SetRules set, Rules replace the routing
+// rules; an empty slice removes them. SetVariants does the same for
+// Variants and StickyVariants. UTM replaces the UTM parameters, an empty
+// value removes them. A zero RedirectType restores the service default.
+// Meta replaces the metadata, an empty value removes it.
+type LinkUpdate struct {
+	URL            *string
+	ExpiresAt      *time.Time
+	ClearExpiry    bool
+	Reschedule     bool
+	ActiveFrom     *time.Time
+	ActiveUntil    *time.Time
+	SetRules       bool
+	Rules          []Rule
+	SetVariants    bool
+	Variants       []Variant
+	StickyVariants bool
+	ForwardQuery   *bool
+	UTM            *UTM
+	RedirectType   *int
+	Title          *string
+	Interstitial   *bool
+	Meta           *Meta
+	Disabled       *bool
+}
+
+// IsLimited reports whether the link may only be followed MaxClicks times.
+func (l Link) IsLimited() bool {
+	return l.MaxClicks > 0
+}
+
+// IsPending reports whether the activation window has not opened by now.
+func (l Link) IsPending(now time.Time) bool {
+	return l.ActiveFrom != nil && now.Before(*l.ActiveFrom)
+}
+
+// IsInactive reports whether the activation window closed by now.
+func (l Link) IsInactive(now time.Time) bool {
+	return l.ActiveUntil != nil && !now.Before(*l.ActiveUntil)
+}
+
+// IsExpired reports whether the link has an expiry date that is not after now.
+func (l Link) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// ExportedLink is a link with its click count as written to and read from
+// export files.
+type ExportedLink struct {
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Clicks    int64      `json:"clicks"`
+}
+
+const (
+	SortByCreatedAt = "created_at"
+	SortByAlias     = "alias"
 )
+
+// ListFilter selects a page of links belonging to OwnerID.
+// Cursor is the opaque NextCursor of the previous page.
+type ListFilter struct {
+	OwnerID     string
+	Query       string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortBy      string
+	Desc        bool
+	Cursor      string
+	Limit       int
+}
+
+// LinkPage is a single page of links. Total counts every link matching
+// the filter, not only those on the page.
+type LinkPage struct {
+	Links      []Link
+	Total      int64
+	NextCursor string
+}
+
+// Click is a single redirect of an alias.
+type Click struct {
+	Alias     string
+	ClickedAt time.Time
+	Referrer  string
+	UserAgent string
+	IP        string
+	RequestID string
+	// Variant is the name of the A/B variant served, empty for links
+	// without variants.
+	Variant string
+}
+
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
+
+// StatsFilter limits click statistics to [From, To) grouped by Bucket.
+type StatsFilter struct {
+	Bucket string
+	From   *time.Time
+	To     *time.Time
+}
+
+type ClickStats struct {
+	Total        int64           `json:"total"`
+	Series       []StatsBucket   `json:"series"`
+	TopReferrers []ReferrerCount `json:"top_referrers"`
+	Variants     []VariantCount  `json:"variants,omitempty"`
+}
+
+type StatsBucket struct {
+	Time  time.Time `json:"time"`
+	Count int64     `json:"count"`
+}
+
+type ReferrerCount struct {
+	Referrer string `json:"referrer"`
+	Count    int64  `json:"count"`
+}
+
+type VariantCount struct {
+	Variant string `json:"variant"`
+	Count   int64  `json:"count"`
+}
+
+// Reasons a link may be reported for.
+const (
+	ReasonPhishing = "phishing"
+	ReasonMalware  = "malware"
+	ReasonSpam     = "spam"
+	ReasonIllegal  = "illegal"
+	ReasonOther    = "other"
+)
+
+// Report is a complaint about a link sent by a visitor.
+type Report struct {
+	ID         int64     `json:"id"`
+	Alias      string    `json:"alias"`
+	Reason     string    `json:"reason"`
+	Comment    string    `json:"comment,omitempty"`
+	ReporterIP string    `json:"reporter_ip"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReportFilter selects a page of reports, newest first. An empty Alias
+// selects reports of every link. Cursor is the NextCursor of the previous
+// page.
+type ReportFilter struct {
+	Alias  string
+	Cursor string
+	Limit  int
+}
+
+// ReportPage is a single page of reports.
+type ReportPage struct {
+	Reports    []Report
+	NextCursor string
+}
+
+// Ban blocks an owner from the API; the links of a banned owner are
+// disabled.
+type Ban struct {
+	OwnerID   string    `json:"owner_id"`
+	Reason    string    `json:"reason,omitempty"`
+	BannedBy  string    `json:"banned_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,80 @@
+package sweeper
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"linkify/internal/config"
+	"time"
+)
+
+type Repository interface {
+	DeleteExpired(now time.Time, batchSize int, archive bool) (int64, error)
+}
+
+// Sweeper periodically purges or archives expired links in batches.
+type Sweeper struct {
+	repo Repository
+	cfg  config.Sweeper
+	log  *zap.SugaredLogger
+	stop chan struct{}
+	done chan struct{}
+}
+
+func New(cfg config.Sweeper, log *zap.SugaredLogger, repo Repository) *Sweeper {
+	return &Sweeper{
+		repo: repo,
+		cfg:  cfg,
+		log:  log,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Run sweeps on every interval until Stop is called.
+func (s *Sweeper) Run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Sweep()
+		}
+	}
+}
+
+// Sweep removes expired links batch by batch until a batch comes back short.
+func (s *Sweeper) Sweep() {
+	now := time.Now()
+	var total int64
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		n, err := s.repo.DeleteExpired(now, s.cfg.BatchSize, s.cfg.Archive)
+		if err != nil {
+			s.log.Errorw("failed to sweep expired links", "error", err)
+			return
+		}
+		total += n
+		if n == 0 || n < int64(s.cfg.BatchSize) {
+			break
+		}
+	}
+	if total > 0 {
+		s.log.Infow("swept expired links", "count", total, "archive", s.cfg.Archive)
+	}
+}
+
+func (s *Sweeper) Stop(ctx context.Context) {
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.log.Error("failed to stop sweeper", zap.Error(ctx.Err()))
+	}
+}
@@ -0,0 +1,67 @@
+package sweeper_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/config"
+	"linkify/internal/sweeper"
+	"linkify/pkg/logger/zapdiscard"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	batches []int64
+	err     error
+	calls   int
+}
+
+func (f *fakeRepo) DeleteExpired(_ time.Time, _ int, _ bool) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	n := f.batches[f.calls]
+	f.calls++
+	return n, nil
+}
+
+func TestSweep(t *testing.T) {
+	cases := []struct {
+		name      string
+		batches   []int64
+		err       error
+		wantCalls int
+	}{
+		{
+			name:      "Nothing expired",
+			batches:   []int64{0},
+			wantCalls: 1,
+		},
+		{
+			name:      "Stops on short batch",
+			batches:   []int64{10, 10, 3},
+			wantCalls: 3,
+		},
+		{
+			name:      "Stops on empty batch",
+			batches:   []int64{10, 0},
+			wantCalls: 2,
+		},
+		{
+			name:      "Stops on error",
+			err:       errors.New("db error"),
+			wantCalls: 0,
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			repo := &fakeRepo{batches: tc.batches, err: tc.err}
+			s := sweeper.New(config.Sweeper{Interval: time.Minute, BatchSize: 10}, zapdiscard.New(), repo)
+			s.Sweep()
+			require.Equal(t, tc.wantCalls, repo.calls)
+		})
+	}
+}
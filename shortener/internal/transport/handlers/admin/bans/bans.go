@@ -0,0 +1,175 @@
+package bans
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"time"
+)
+
+// Request gives the reason of a ban.
+type Request struct {
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// Response represents the ban and how many links it disabled.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Ban
+	DisabledLinks int `json:"disabled_links"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=Banner
+type Banner interface {
+	BanOwner(ban storage.Ban) ([]string, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=Unbanner
+type Unbanner interface {
+	UnbanOwner(ownerID string) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// New handles banning a link owner.
+// @Summary      Ban owner
+// @Description  Blocks the owner from the API and disables all of the owner's links. Admins only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        owner_id  path      string   true   "Owner ID"
+// @Param        request   body      Request  false  "Ban reason"
+// @Success      200       {object}  Response
+// @Failure      400       {object}  response.Response  "Invalid request"
+// @Failure      401       {object}  response.Response  "Unauthorized"
+// @Failure      403       {object}  response.Response  "Not an admin"
+// @Failure      500       {object}  response.Response  "Internal server error"
+// @Router       /api/admin/owners/{owner_id}/ban [post]
+func New(log *zap.SugaredLogger, banner Banner, cacheDeleter CacheDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		adminID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		ownerID := chi.URLParam(r, "owner_id")
+		if ownerID == "" {
+			log.Info("owner id is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		if ownerID == adminID {
+			log.Infow("admin tried to ban themselves", "admin_id", adminID)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("cannot ban yourself"))
+			return
+		}
+
+		var req Request
+		if r.ContentLength != 0 {
+			if err := render.DecodeJSON(r.Body, &req); err != nil {
+				log.Error("failed to decode request", zap.Error(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("failed to decode request"))
+				return
+			}
+		}
+		if err := validator.New().Struct(req); err != nil {
+			var validateErrs validator.ValidationErrors
+			errors.As(err, &validateErrs)
+
+			log.Error("failed to validate request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidateError(validateErrs))
+			return
+		}
+
+		ban := storage.Ban{
+			OwnerID:   ownerID,
+			Reason:    req.Reason,
+			BannedBy:  adminID,
+			CreatedAt: time.Now(),
+		}
+		aliases, err := banner.BanOwner(ban)
+		if err != nil {
+			log.Error("failed to ban owner", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to ban owner"))
+			return
+		}
+		for _, alias := range aliases {
+			if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+				log.Errorw("failed to delete alias from cache", "alias", alias, "error", err)
+			}
+		}
+
+		log.Infow("owner banned", "owner_id", ownerID, "admin_id", adminID, "disabled_links", len(aliases))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response:      resp.OK(),
+			Ban:           ban,
+			DisabledLinks: len(aliases),
+		})
+	}
+}
+
+// NewUnban handles lifting the ban of a link owner.
+// @Summary      Unban owner
+// @Description  Lets the owner use the API again. Links disabled by the ban stay disabled until enabled one by one. Admins only
+// @Tags         admin
+// @Security     ApiKeyAuth
+// @Param        owner_id  path  string  true  "Owner ID"
+// @Success      204  "Ban lifted"
+// @Failure      401  {object}  response.Response  "Unauthorized"
+// @Failure      403  {object}  response.Response  "Not an admin"
+// @Failure      404  {object}  response.Response  "Owner is not banned"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/admin/owners/{owner_id}/ban [delete]
+func NewUnban(log *zap.SugaredLogger, unbanner Unbanner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID := chi.URLParam(r, "owner_id")
+		if ownerID == "" {
+			log.Info("owner id is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		if err := unbanner.UnbanOwner(ownerID); err != nil {
+			if errors.Is(err, storage.ErrBanNotFound) {
+				log.Infow("owner is not banned", "owner_id", ownerID)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("owner is not banned"))
+				return
+			}
+			log.Error("failed to unban owner", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to unban owner"))
+			return
+		}
+		log.Infow("owner unbanned", "owner_id", ownerID)
+		render.Status(r, http.StatusNoContent)
+		render.NoContent(w, r)
+	}
+}
@@ -0,0 +1,142 @@
+package bans_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/admin/bans"
+	mocker "linkify/internal/transport/handlers/admin/bans/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const adminID = "1"
+
+func newRequest(t *testing.T, method, ownerID, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, "/admin/owners/"+ownerID+"/ban", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("owner_id", ownerID)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	return req.WithContext(auth.WithUser(ctx, adminID, "admin@example.com", true))
+}
+
+func TestBanHandler(t *testing.T) {
+	const ownerID = "42"
+	cases := []struct {
+		name       string
+		ownerID    string
+		body       string
+		ban        bool
+		aliases    []string
+		mockError  error
+		statusCode int
+		respError  string
+	}{
+		{
+			name:       "Ban",
+			ownerID:    ownerID,
+			body:       `{"reason":"phishing campaign"}`,
+			ban:        true,
+			aliases:    []string{"bank-login", "promo"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Ban without reason",
+			ownerID:    ownerID,
+			ban:        true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Reason too long",
+			ownerID:    ownerID,
+			body:       `{"reason":"` + strings.Repeat("a", 501) + `"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Reason is not valid",
+		},
+		{
+			name:       "Ban yourself",
+			ownerID:    adminID,
+			statusCode: http.StatusBadRequest,
+			respError:  "cannot ban yourself",
+		},
+		{
+			name:       "Storage error",
+			ownerID:    ownerID,
+			ban:        true,
+			mockError:  errors.New("unexpected error"),
+			statusCode: http.StatusInternalServerError,
+			respError:  "failed to ban owner",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			bannerMock := mocker.NewBanner(t)
+			cacheDeleterMock := mocker.NewCacheDeleter(t)
+			if tc.ban {
+				isExpected := func(ban storage.Ban) bool {
+					return ban.OwnerID == tc.ownerID && ban.BannedBy == adminID && !ban.CreatedAt.IsZero()
+				}
+				bannerMock.On("BanOwner", mock.MatchedBy(isExpected)).Return(tc.aliases, tc.mockError).Once()
+			}
+			for _, alias := range tc.aliases {
+				cacheDeleterMock.On("Delete", mock.Anything, alias).Return(nil).Once()
+			}
+
+			handler := bans.New(zapdiscard.New(), bannerMock, cacheDeleterMock)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newRequest(t, http.MethodPost, tc.ownerID, tc.body))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			var resp bans.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, tc.ownerID, resp.OwnerID)
+				require.Equal(t, len(tc.aliases), resp.DisabledLinks)
+			}
+		})
+	}
+}
+
+func TestUnbanHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		mockError  error
+		statusCode int
+	}{
+		{name: "Unban", statusCode: http.StatusNoContent},
+		{name: "Not banned", mockError: storage.ErrBanNotFound, statusCode: http.StatusNotFound},
+		{name: "Storage error", mockError: errors.New("unexpected error"), statusCode: http.StatusInternalServerError},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			unbannerMock := mocker.NewUnbanner(t)
+			unbannerMock.On("UnbanOwner", "42").Return(tc.mockError).Once()
+
+			handler := bans.NewUnban(zapdiscard.New(), unbannerMock)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newRequest(t, http.MethodDelete, "42", ""))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package links
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/etag"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+)
+
+// Response represents the link after the change.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Link
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLUpdater
+type URLUpdater interface {
+	Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// New handles disabling a link by its alias, or enabling it again when
+// disabled is false.
+// @Summary      Disable or enable URL
+// @Description  Takes a link down or restores it. Disabled links answer 451 Unavailable For Legal Reasons instead of redirecting. Admins only
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias  path      string  true  "URL alias"
+// @Success      200    {object}  Response
+// @Failure      401    {object}  response.Response  "Unauthorized"
+// @Failure      403    {object}  response.Response  "Not an admin"
+// @Failure      404    {object}  response.Response  "Alias not found"
+// @Failure      500    {object}  response.Response  "Internal server error"
+// @Router       /api/admin/url/{alias}/disable [post]
+// @Router       /api/admin/url/{alias}/enable [post]
+func New(log *zap.SugaredLogger, urlUpdater URLUpdater, cacheDeleter CacheDeleter, disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		adminID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+
+		link, err := urlUpdater.Update(alias, adminID, true, 0, storage.LinkUpdate{Disabled: &disabled})
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+				return
+			}
+			log.Error("failed to change link state", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to change link state"))
+			return
+		}
+		if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+			log.Error("failed to delete alias from cache", zap.Error(err))
+		}
+
+		log.Infow("link state changed", "alias", alias, "disabled", disabled, "admin_id", adminID)
+		w.Header().Set("ETag", etag.Format(link.Version))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     *link,
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package links_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/api/etag"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/admin/links"
+	mocker "linkify/internal/transport/handlers/admin/links/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLinksHandler(t *testing.T) {
+	const (
+		alias   = "bad"
+		adminID = "1"
+	)
+	cases := []struct {
+		name       string
+		disabled   bool
+		mockError  error
+		statusCode int
+		respError  string
+	}{
+		{
+			name:       "Disable",
+			disabled:   true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Enable",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Not found",
+			disabled:   true,
+			mockError:  storage.ErrURLNotFound,
+			statusCode: http.StatusNotFound,
+			respError:  "alias not found",
+		},
+		{
+			name:       "Storage error",
+			disabled:   true,
+			mockError:  errors.New("unexpected error"),
+			statusCode: http.StatusInternalServerError,
+			respError:  "failed to change link state",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocker.NewURLUpdater(t)
+			cacheDeleterMock := mocker.NewCacheDeleter(t)
+			var result *storage.Link
+			if tc.mockError == nil {
+				result = &storage.Link{Alias: alias, URL: "https://example.com", OwnerID: "42", Version: 3, Disabled: tc.disabled}
+				cacheDeleterMock.On("Delete", mock.Anything, alias).Return(nil).Once()
+			}
+			urlUpdaterMock.On("Update", alias, adminID, true, 0, storage.LinkUpdate{Disabled: &tc.disabled}).
+				Return(result, tc.mockError).
+				Once()
+
+			handler := links.New(zapdiscard.New(), urlUpdaterMock, cacheDeleterMock, tc.disabled)
+			req, err := http.NewRequest(http.MethodPost, "/admin/url/"+alias+"/disable", nil)
+			require.NoError(t, err)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, adminID, "admin@example.com", true))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			var resp links.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, etag.Format(3), rr.Header().Get("ETag"))
+				require.Equal(t, tc.disabled, resp.Disabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package reports
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// Response represents a page of reports.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	Items      []storage.Report `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=ReportLister
+type ReportLister interface {
+	ListReports(filter storage.ReportFilter) (*storage.ReportPage, error)
+}
+
+// New handles listing the abuse reports for admins.
+// @Summary      List reports
+// @Description  Returns the abuse reports, newest first. Admins only
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias   query     string  false  "Only reports of this alias"
+// @Param        limit   query     int     false  "Page size (1-200)"
+// @Param        cursor  query     string  false  "Cursor from the previous page"
+// @Success      200  {object}  Response
+// @Failure      400  {object}  response.Response  "Invalid request"
+// @Failure      401  {object}  response.Response  "Unauthorized"
+// @Failure      403  {object}  response.Response  "Not an admin"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/admin/reports [get]
+func New(log *zap.SugaredLogger, reportLister ReportLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		query := r.URL.Query()
+		filter := storage.ReportFilter{
+			Alias:  query.Get("alias"),
+			Cursor: query.Get("cursor"),
+			Limit:  defaultLimit,
+		}
+		if raw := query.Get("limit"); raw != "" {
+			limit, err := strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > maxLimit {
+				log.Infow("invalid limit", "limit", raw)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid limit"))
+				return
+			}
+			filter.Limit = limit
+		}
+
+		page, err := reportLister.ListReports(filter)
+		if err != nil {
+			if errors.Is(err, storage.ErrInvalidCursor) {
+				log.Infow("invalid cursor", "cursor", filter.Cursor)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid cursor"))
+				return
+			}
+			log.Error("failed to list reports", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to list reports"))
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Items:      page.Reports,
+			NextCursor: page.NextCursor,
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package reports_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/admin/reports"
+	mocker "linkify/internal/transport/handlers/admin/reports/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReportsHandler(t *testing.T) {
+	page := &storage.ReportPage{
+		Reports: []storage.Report{
+			{ID: 9, Alias: "bad", Reason: storage.ReasonPhishing, ReporterIP: "203.0.113.5", CreatedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: 7, Alias: "bad", Reason: storage.ReasonSpam, ReporterIP: "203.0.113.6", CreatedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		NextCursor: "7",
+	}
+	cases := []struct {
+		name       string
+		query      string
+		filter     *storage.ReportFilter
+		mockError  error
+		statusCode int
+		respError  string
+	}{
+		{
+			name:       "Default page",
+			filter:     &storage.ReportFilter{Limit: 50},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Filtered page",
+			query:      "?alias=bad&limit=2&cursor=12",
+			filter:     &storage.ReportFilter{Alias: "bad", Limit: 2, Cursor: "12"},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid limit",
+			query:      "?limit=500",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid limit",
+		},
+		{
+			name:       "Invalid cursor",
+			query:      "?cursor=abc",
+			filter:     &storage.ReportFilter{Limit: 50, Cursor: "abc"},
+			mockError:  storage.ErrInvalidCursor,
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid cursor",
+		},
+		{
+			name:       "Storage error",
+			filter:     &storage.ReportFilter{Limit: 50},
+			mockError:  errors.New("unexpected error"),
+			statusCode: http.StatusInternalServerError,
+			respError:  "failed to list reports",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			listerMock := mocker.NewReportLister(t)
+			if tc.filter != nil {
+				var result *storage.ReportPage
+				if tc.mockError == nil {
+					result = page
+				}
+				listerMock.On("ListReports", *tc.filter).Return(result, tc.mockError).Once()
+			}
+
+			handler := reports.New(zapdiscard.New(), listerMock)
+			req, err := http.NewRequest(http.MethodGet, "/admin/reports"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), "1", "admin@example.com", true))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			var resp reports.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, page.Reports, resp.Items)
+				require.Equal(t, page.NextCursor, resp.NextCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package delete
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+)
+
+// BatchRequest lists the aliases to delete at once.
+type BatchRequest struct {
+	Aliases []string `json:"aliases"`
+}
+
+// BatchItem is the outcome of a single alias of a batch. Status holds the
+// HTTP status the alias would get from DELETE /api/url/{alias}.
+type BatchItem struct {
+	Alias  string `json:"alias"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResponse holds the results in the order of the requested aliases.
+type BatchResponse struct {
+	resp.Response `swaggertype:"object,string"`
+
+	Items []BatchItem `json:"items"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=BatchDeleter
+type BatchDeleter interface {
+	DeleteBatch(aliases []string, ownerID string, isAdmin bool) ([]error, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=MetricsBatchDeleter
+type MetricsBatchDeleter interface {
+	IncLinksDeleted()
+	ObserveBatchSize(operation string, size int)
+}
+
+// NewBatch handles the deletion of several URLs in one request.
+// @Summary      Delete URLs
+// @Description  Deletes up to the configured limit of aliases at once. Aliases of other users are skipped unless the caller is an admin
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body BatchRequest true "Aliases to delete"
+// @Success      200  {object}  BatchResponse  "Per-item results"
+// @Failure      400  {object}  response.Response  "Invalid request or too many items"
+// @Failure      401  {object}  response.Response  "Unauthorized"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/urls/batch-delete [post]
+func NewBatch(log *zap.SugaredLogger, deleter BatchDeleter, cacheDeleter CacheDeleter, limit int, m MetricsBatchDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+
+		var req BatchRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		if len(req.Aliases) == 0 || len(req.Aliases) > limit {
+			log.Infow("invalid batch size", "size", len(req.Aliases))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(fmt.Sprintf("batch must contain from 1 to %d aliases", limit)))
+			return
+		}
+		m.ObserveBatchSize("delete", len(req.Aliases))
+
+		errs, err := deleter.DeleteBatch(req.Aliases, ownerID, auth.IsAdminFromContext(r.Context()))
+		if err != nil {
+			log.Error("failed to delete aliases", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to delete aliases"))
+			return
+		}
+
+		items := make([]BatchItem, len(req.Aliases))
+		for i, alias := range req.Aliases {
+			items[i].Alias = alias
+			switch {
+			case errs[i] == nil:
+				items[i].Status = http.StatusNoContent
+				if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+					log.Errorw("failed to delete alias from cache", "alias", alias, "error", err)
+				}
+				m.IncLinksDeleted()
+			case errors.Is(errs[i], storage.ErrURLNotFound):
+				items[i].Status = http.StatusNotFound
+				items[i].Error = "alias not found"
+			case errors.Is(errs[i], storage.ErrForbidden):
+				items[i].Status = http.StatusForbidden
+				items[i].Error = "access denied"
+			default:
+				items[i].Status = http.StatusInternalServerError
+				items[i].Error = "failed to delete alias"
+			}
+		}
+
+		log.Infow("batch of aliases deleted", "size", len(items), "owner_id", ownerID)
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, BatchResponse{
+			Response: resp.OK(),
+			Items:    items,
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package delete_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/delete"
+	mocker "linkify/internal/transport/handlers/url/delete/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchDeleteHandler(t *testing.T) {
+	const ownerID = "42"
+
+	newRequest := func(t *testing.T, body string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/urls/batch-delete", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		return req.WithContext(auth.WithUser(req.Context(), ownerID, "user@example.com", false))
+	}
+
+	t.Run("Per-item results", func(t *testing.T) {
+		deleterMock := mocker.NewBatchDeleter(t)
+		cacheMock := mocker.NewCacheDeleter(t)
+		metricsMock := mocker.NewMetricsBatchDeleter(t)
+		aliases := []string{"mine", "missing", "foreign"}
+		metricsMock.On("ObserveBatchSize", "delete", len(aliases)).Once()
+		metricsMock.On("IncLinksDeleted").Once()
+		deleterMock.On("DeleteBatch", aliases, ownerID, false).
+			Return([]error{nil, storage.ErrURLNotFound, storage.ErrForbidden}, nil).
+			Once()
+		cacheMock.On("Delete", mock.Anything, "mine").
+			Return(nil).
+			Once()
+
+		handler := delete.NewBatch(zapdiscard.New(), deleterMock, cacheMock, 10, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, `{"aliases":["mine","missing","foreign"]}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp delete.BatchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, []delete.BatchItem{
+			{Alias: "mine", Status: http.StatusNoContent},
+			{Alias: "missing", Status: http.StatusNotFound, Error: "alias not found"},
+			{Alias: "foreign", Status: http.StatusForbidden, Error: "access denied"},
+		}, resp.Items)
+	})
+
+	t.Run("Empty batch", func(t *testing.T) {
+		handler := delete.NewBatch(zapdiscard.New(), mocker.NewBatchDeleter(t), mocker.NewCacheDeleter(t), 10, mocker.NewMetricsBatchDeleter(t))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, `{"aliases":[]}`))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		deleterMock := mocker.NewBatchDeleter(t)
+		metricsMock := mocker.NewMetricsBatchDeleter(t)
+		metricsMock.On("ObserveBatchSize", "delete", 1).Once()
+		deleterMock.On("DeleteBatch", []string{"mine"}, ownerID, false).
+			Return(nil, errors.New("unexpected error")).
+			Once()
+
+		handler := delete.NewBatch(zapdiscard.New(), deleterMock, mocker.NewCacheDeleter(t), 10, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, `{"aliases":["mine"]}`))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
@@ -9,12 +9,13 @@ import (
 	"go.uber.org/zap"
 	resp "linkify/internal/lib/api/response"
 	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
 	"net/http"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLDeleter
 type URLDeleter interface {
-	Delete(alias string) error
+	Delete(alias string, ownerID string, isAdmin bool) error
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
@@ -29,7 +30,7 @@ type MetricsDeleter interface {
 
 // New handles the deletion of a URL by its alias.
 // @Summary      Delete URL
-// @Description  Deletes a saved URL using its alias
+// @Description  Deletes a saved URL using its alias. Only the owner or an admin may delete it
 // @Tags         url
 // @Accept       json
 // @Produce      json
@@ -38,6 +39,7 @@ type MetricsDeleter interface {
 // @Success      204     "No Content"
 // @Failure      400     {object}  response.Response  "Invalid request"
 // @Failure      401     {object}  response.Response  "Unauthorized"
+// @Failure      403     {object}  response.Response  "Alias belongs to another user"
 // @Failure      404     {object}  response.Response  "Alias not found"
 // @Failure      500     {object}  response.Response  "Internal server error"
 // @Router       /api/url/{alias} [delete]
@@ -46,6 +48,13 @@ func New(log *zap.SugaredLogger, URLDeleter URLDeleter, CacheDeleter CacheDelete
 		log := log.With(
 			"request_id", middleware.GetReqID(r.Context()),
 		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Info("alias is empty")
@@ -53,11 +62,7 @@ func New(log *zap.SugaredLogger, URLDeleter URLDeleter, CacheDeleter CacheDelete
 			render.JSON(w, r, resp.Error("invalid request"))
 			return
 		}
-		err := CacheDeleter.Delete(r.Context(), alias)
-		if err != nil {
-			log.Error("failed to delete alias from cache", zap.Error(err))
-		}
-		err = URLDeleter.Delete(alias)
+		err := URLDeleter.Delete(alias, ownerID, auth.IsAdminFromContext(r.Context()))
 		if err != nil {
 			if errors.Is(err, storage.ErrURLNotFound) {
 				log.Infow("alias not found", "alias", alias)
@@ -65,11 +70,20 @@ func New(log *zap.SugaredLogger, URLDeleter URLDeleter, CacheDeleter CacheDelete
 				render.JSON(w, r, resp.Error("alias not found"))
 				return
 			}
+			if errors.Is(err, storage.ErrForbidden) {
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
 			log.Error("failed to get alias", zap.Error(err))
 			render.Status(r, http.StatusInternalServerError)
 			render.JSON(w, r, resp.Error("failed to get alias"))
 			return
 		}
+		if err = CacheDeleter.Delete(r.Context(), alias); err != nil {
+			log.Error("failed to delete alias from cache", zap.Error(err))
+		}
 		log.Infow("delete alias", "alias", alias)
 		m.IncLinksDeleted()
 		render.Status(r, http.StatusNoContent)
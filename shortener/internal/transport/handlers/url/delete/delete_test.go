@@ -10,6 +10,7 @@ import (
 	"linkify/internal/storage"
 	del "linkify/internal/transport/handlers/url/delete"
 	mocker "linkify/internal/transport/handlers/url/delete/mocks"
+	"linkify/internal/transport/middleware/auth"
 	"linkify/pkg/logger/zapdiscard"
 	"net/http"
 	"net/http/httptest"
@@ -25,6 +26,8 @@ func TestDeleteHandler(t *testing.T) {
 		statusCode   int
 		expectCache  bool
 		expectDelete bool
+		isAdmin      bool
+		anonymous    bool
 	}{
 		{
 			name:         "Success",
@@ -33,6 +36,27 @@ func TestDeleteHandler(t *testing.T) {
 			expectCache:  true,
 			expectDelete: true,
 		},
+		{
+			name:         "Admin deletes foreign alias",
+			alias:        "alias",
+			statusCode:   http.StatusNoContent,
+			expectCache:  true,
+			expectDelete: true,
+			isAdmin:      true,
+		},
+		{
+			name:         "Alias owned by another user",
+			alias:        "alias",
+			mockError:    storage.ErrForbidden,
+			statusCode:   http.StatusForbidden,
+			expectDelete: true,
+		},
+		{
+			name:       "Unauthorized",
+			alias:      "alias",
+			statusCode: http.StatusUnauthorized,
+			anonymous:  true,
+		},
 		{
 			name:        "Empty alias",
 			alias:       "",
@@ -44,7 +68,6 @@ func TestDeleteHandler(t *testing.T) {
 			alias:        "non_existent_alias",
 			mockError:    storage.ErrURLNotFound,
 			statusCode:   http.StatusNotFound,
-			expectCache:  true,
 			expectDelete: true,
 		},
 		{
@@ -52,7 +75,6 @@ func TestDeleteHandler(t *testing.T) {
 			alias:        "alias",
 			mockError:    errors.New("failed to delete URL"),
 			statusCode:   http.StatusInternalServerError,
-			expectCache:  true,
 			expectDelete: true,
 		},
 		{
@@ -64,6 +86,7 @@ func TestDeleteHandler(t *testing.T) {
 			expectDelete: true,
 		},
 	}
+	const ownerID = "42"
 	t.Parallel()
 	for _, tc := range cases {
 		tc := tc
@@ -81,7 +104,7 @@ func TestDeleteHandler(t *testing.T) {
 			}
 
 			if tc.expectDelete {
-				urlDeleterMock.On("Delete", tc.alias).
+				urlDeleterMock.On("Delete", tc.alias, ownerID, tc.isAdmin).
 					Return(tc.mockError).
 					Once()
 			}
@@ -97,7 +120,11 @@ func TestDeleteHandler(t *testing.T) {
 
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("alias", tc.alias)
-			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			if !tc.anonymous {
+				ctx = auth.WithUser(ctx, ownerID, "user@example.com", tc.isAdmin)
+			}
+			req = req.WithContext(ctx)
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
@@ -9,17 +9,17 @@ type URLDeleter struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: alias
-func (_m *URLDeleter) Delete(alias string) error {
-	ret := _m.Called(alias)
+// Delete provides a mock function with given fields: alias, ownerID, isAdmin
+func (_m *URLDeleter) Delete(alias string, ownerID string, isAdmin bool) error {
+	ret := _m.Called(alias, ownerID, isAdmin)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string, bool) error); ok {
+		r0 = rf(alias, ownerID, isAdmin)
 	} else {
 		r0 = ret.Error(0)
 	}
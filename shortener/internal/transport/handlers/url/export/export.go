@@ -0,0 +1,95 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	"io"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/lib/linkfile"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"time"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLExporter
+type URLExporter interface {
+	ExportByOwner(ctx context.Context, ownerID string, fn func(link storage.ExportedLink) error) error
+}
+
+// New handles exporting every link of the authenticated user.
+// @Summary      Export URLs
+// @Description  Streams all links of the user with their click counts as CSV or JSON Lines
+// @Tags         url
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Security     ApiKeyAuth
+// @Param        format  query     string  false  "File format: csv (default) or jsonl"
+// @Success      200     {file}    file
+// @Failure      400     {object}  response.Response  "Invalid request"
+// @Failure      401     {object}  response.Response  "Unauthorized"
+// @Failure      500     {object}  response.Response  "Internal server error"
+// @Router       /api/urls/export [get]
+func New(log *zap.SugaredLogger, exporter URLExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		format, err := linkfile.ParseFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			log.Infow("invalid export format", "format", r.URL.Query().Get("format"))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		// The export may outlive the server write timeout.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="links.%s"`, format))
+		out := &trackingWriter{w: w}
+		writer := linkfile.NewWriter(out, format)
+		count := 0
+		err = exporter.ExportByOwner(r.Context(), ownerID, func(link storage.ExportedLink) error {
+			count++
+			return writer.Write(link)
+		})
+		if err == nil {
+			err = writer.Flush()
+		}
+		if err != nil {
+			log.Error("failed to export urls", zap.Error(err), zap.Int("exported", count))
+			// Once data is sent the status cannot change, so the client
+			// only sees a truncated file.
+			if !out.written {
+				w.Header().Del("Content-Disposition")
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to export urls"))
+			}
+			return
+		}
+		log.Infow("urls exported", "count", count, "owner_id", ownerID)
+	}
+}
+
+// trackingWriter records whether anything reached the response.
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
@@ -0,0 +1,98 @@
+package export_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/export"
+	mocker "linkify/internal/transport/handlers/url/export/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExportHandler(t *testing.T) {
+	const ownerID = "42"
+	link := storage.ExportedLink{
+		Alias:     "promo",
+		URL:       "https://example.com",
+		CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Clicks:    7,
+	}
+	cases := []struct {
+		name        string
+		query       string
+		expectCall  bool
+		mockError   error
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "CSV",
+			expectCall:  true,
+			statusCode:  http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			body:        "alias,url,created_at,expires_at,clicks\npromo,https://example.com,2025-01-01T00:00:00Z,,7\n",
+		},
+		{
+			name:        "JSON Lines",
+			query:       "?format=jsonl",
+			expectCall:  true,
+			statusCode:  http.StatusOK,
+			contentType: "application/x-ndjson",
+			body:        `{"alias":"promo","url":"https://example.com","created_at":"2025-01-01T00:00:00Z","clicks":7}` + "\n",
+		},
+		{
+			name:        "Unknown format",
+			query:       "?format=xml",
+			statusCode:  http.StatusBadRequest,
+			contentType: "application/json",
+		},
+		{
+			name:        "Storage error",
+			expectCall:  true,
+			mockError:   errors.New("unexpected error"),
+			statusCode:  http.StatusInternalServerError,
+			contentType: "application/json",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporterMock := mocker.NewURLExporter(t)
+			if tc.expectCall {
+				exporterMock.On("ExportByOwner", mock.Anything, ownerID, mock.Anything).
+					Return(func(_ context.Context, _ string, fn func(storage.ExportedLink) error) error {
+						if tc.mockError != nil {
+							return tc.mockError
+						}
+						return fn(link)
+					}).
+					Once()
+			}
+
+			handler := export.New(zapdiscard.New(), exporterMock)
+			req, err := http.NewRequest(http.MethodGet, "/urls/export"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), ownerID, "user@example.com", false))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			require.Contains(t, rr.Header().Get("Content-Type"), tc.contentType)
+			if tc.body != "" {
+				require.Equal(t, tc.body, rr.Body.String())
+			}
+		})
+	}
+}
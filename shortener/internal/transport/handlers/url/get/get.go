@@ -0,0 +1,89 @@
+package get
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/etag"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+)
+
+// Response represents a single link of the user.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Link
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLGetter
+type URLGetter interface {
+	Get(alias string) (*storage.Link, error)
+}
+
+// New handles reading a link by its alias.
+// @Summary      Get URL
+// @Description  Returns the link with its current version in the ETag header. Only the owner or an admin may read it
+// @Tags         url
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias   path      string  true  "URL alias"
+// @Success      200     {object}  Response
+// @Failure      400     {object}  response.Response  "Invalid request"
+// @Failure      401     {object}  response.Response  "Unauthorized"
+// @Failure      403     {object}  response.Response  "Alias belongs to another user"
+// @Failure      404     {object}  response.Response  "Alias not found"
+// @Failure      500     {object}  response.Response  "Internal server error"
+// @Router       /api/url/{alias} [get]
+func New(log *zap.SugaredLogger, urlGetter URLGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+
+		link, err := urlGetter.Get(alias)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+				return
+			}
+			log.Error("failed to get url", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+		if link.OwnerID != ownerID && !auth.IsAdminFromContext(r.Context()) {
+			log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("access denied"))
+			return
+		}
+
+		w.Header().Set("ETag", etag.Format(link.Version))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     *link,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package get_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/api/etag"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/get"
+	mocker "linkify/internal/transport/handlers/url/get/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetHandler(t *testing.T) {
+	const (
+		alias   = "alias"
+		ownerID = "42"
+	)
+	cases := []struct {
+		name       string
+		linkOwner  string
+		isAdmin    bool
+		mockError  error
+		statusCode int
+		respError  string
+	}{
+		{
+			name:       "Success",
+			linkOwner:  ownerID,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Admin",
+			linkOwner:  "7",
+			isAdmin:    true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Not owner",
+			linkOwner:  "7",
+			statusCode: http.StatusForbidden,
+			respError:  "access denied",
+		},
+		{
+			name:       "Not found",
+			mockError:  storage.ErrURLNotFound,
+			statusCode: http.StatusNotFound,
+			respError:  "alias not found",
+		},
+		{
+			name:       "Storage error",
+			mockError:  errors.New("unexpected error"),
+			statusCode: http.StatusInternalServerError,
+			respError:  "failed to get url",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocker.NewURLGetter(t)
+			var link *storage.Link
+			if tc.mockError == nil {
+				link = &storage.Link{Alias: alias, URL: "https://example.com", OwnerID: tc.linkOwner, Version: 2}
+			}
+			urlGetterMock.On("Get", alias).
+				Return(link, tc.mockError).
+				Once()
+
+			handler := get.New(zapdiscard.New(), urlGetterMock)
+			req, err := http.NewRequest(http.MethodGet, "/url/"+alias, nil)
+			require.NoError(t, err)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, ownerID, "user@example.com", tc.isAdmin))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp get.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, alias, resp.Alias)
+				require.Equal(t, etag.Format(2), rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
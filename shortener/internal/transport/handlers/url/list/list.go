@@ -0,0 +1,156 @@
+package list
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// Response represents a page of the user's links.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	Items      []storage.Link `json:"items"`
+	Total      int64          `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLLister
+type URLLister interface {
+	ListByOwner(filter storage.ListFilter) (*storage.LinkPage, error)
+}
+
+// New handles listing the links of the authenticated user.
+// @Summary      List URLs
+// @Description  Returns a cursor-paginated list of the user's links
+// @Tags         url
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        q             query     string  false  "Substring of the target URL or alias"
+// @Param        created_from  query     string  false  "Lower bound of created_at (RFC 3339, inclusive)"
+// @Param        created_to    query     string  false  "Upper bound of created_at (RFC 3339, exclusive)"
+// @Param        sort          query     string  false  "Sort field: created_at or alias"
+// @Param        order         query     string  false  "Sort order: asc or desc"
+// @Param        limit         query     int     false  "Page size (1-100)"
+// @Param        cursor        query     string  false  "Cursor from the previous page"
+// @Success      200  {object}  Response
+// @Failure      400  {object}  response.Response  "Invalid request"
+// @Failure      401  {object}  response.Response  "Unauthorized"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/urls [get]
+func New(log *zap.SugaredLogger, urlLister URLLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+
+		filter, err := parseFilter(r.URL.Query())
+		if err != nil {
+			log.Infow("invalid list request", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+		filter.OwnerID = ownerID
+
+		page, err := urlLister.ListByOwner(filter)
+		if err != nil {
+			if errors.Is(err, storage.ErrInvalidCursor) {
+				log.Infow("invalid cursor", "cursor", filter.Cursor)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid cursor"))
+				return
+			}
+			log.Error("failed to list urls", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to list urls"))
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Items:      page.Links,
+			Total:      page.Total,
+			NextCursor: page.NextCursor,
+		})
+	}
+}
+
+func parseFilter(query url.Values) (storage.ListFilter, error) {
+	filter := storage.ListFilter{
+		Query:  query.Get("q"),
+		Cursor: query.Get("cursor"),
+		Limit:  defaultLimit,
+		SortBy: storage.SortByCreatedAt,
+		Desc:   true,
+	}
+
+	switch sortBy := query.Get("sort"); sortBy {
+	case "", storage.SortByCreatedAt:
+	case storage.SortByAlias:
+		filter.SortBy = sortBy
+		filter.Desc = false
+	default:
+		return filter, errors.New("invalid sort field")
+	}
+
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		filter.Desc = false
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.New("invalid sort order")
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTime(query, "created_from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTime(query, "created_to"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseTime(query url.Values, param string) (*time.Time, error) {
+	raw := query.Get(param)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, errors.New("invalid " + param)
+	}
+	return &t, nil
+}
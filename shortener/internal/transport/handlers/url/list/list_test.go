@@ -0,0 +1,154 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/list"
+	mocker "linkify/internal/transport/handlers/url/list/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListHandler(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	page := &storage.LinkPage{
+		Links: []storage.Link{
+			{Alias: "abc123", URL: "https://example.com", CreatedAt: from},
+		},
+		Total:      3,
+		NextCursor: "next",
+	}
+	cases := []struct {
+		name       string
+		query      string
+		filter     storage.ListFilter
+		page       *storage.LinkPage
+		mockError  error
+		expectList bool
+		anonymous  bool
+		statusCode int
+		respError  string
+	}{
+		{
+			name:  "Defaults",
+			query: "",
+			filter: storage.ListFilter{
+				SortBy: storage.SortByCreatedAt,
+				Desc:   true,
+				Limit:  20,
+			},
+			page:       page,
+			expectList: true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:  "Search sorted by alias",
+			query: "?q=exam&sort=alias&limit=1&cursor=abc&created_from=2025-01-01T00:00:00Z",
+			filter: storage.ListFilter{
+				Query:       "exam",
+				SortBy:      storage.SortByAlias,
+				Cursor:      "abc",
+				Limit:       1,
+				CreatedFrom: &from,
+			},
+			page:       page,
+			expectList: true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Invalid sort",
+			query:      "?sort=url",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid sort field",
+		},
+		{
+			name:       "Invalid limit",
+			query:      "?limit=1000",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid limit",
+		},
+		{
+			name:       "Invalid date",
+			query:      "?created_to=yesterday",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid created_to",
+		},
+		{
+			name:  "Invalid cursor",
+			query: "?cursor=broken",
+			filter: storage.ListFilter{
+				SortBy: storage.SortByCreatedAt,
+				Desc:   true,
+				Cursor: "broken",
+				Limit:  20,
+			},
+			mockError:  storage.ErrInvalidCursor,
+			expectList: true,
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid cursor",
+		},
+		{
+			name:  "Storage error",
+			query: "",
+			filter: storage.ListFilter{
+				SortBy: storage.SortByCreatedAt,
+				Desc:   true,
+				Limit:  20,
+			},
+			mockError:  errors.New("unexpected error"),
+			expectList: true,
+			statusCode: http.StatusInternalServerError,
+			respError:  "failed to list urls",
+		},
+		{
+			name:       "Unauthorized",
+			anonymous:  true,
+			statusCode: http.StatusUnauthorized,
+			respError:  "Unauthorized",
+		},
+	}
+	const ownerID = "42"
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlListerMock := mocker.NewURLLister(t)
+			if tc.expectList {
+				filter := tc.filter
+				filter.OwnerID = ownerID
+				urlListerMock.On("ListByOwner", filter).
+					Return(tc.page, tc.mockError).
+					Once()
+			}
+
+			handler := list.New(zapdiscard.New(), urlListerMock)
+			req, err := http.NewRequest(http.MethodGet, "/urls"+tc.query, nil)
+			require.NoError(t, err)
+			if !tc.anonymous {
+				req = req.WithContext(auth.WithUser(req.Context(), ownerID, "user@example.com", false))
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, tc.page.Total, resp.Total)
+				require.Equal(t, tc.page.NextCursor, resp.NextCursor)
+				require.Len(t, resp.Items, len(tc.page.Links))
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/etag"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+)
+
+// Request replaces the link card shown by chat apps and social networks.
+// With Fetch the metadata is read from the destination page first and the
+// fields set in the request override the fetched ones. An empty request
+// removes the card. Image is screened like a link destination.
+type Request struct {
+	Title       string `json:"title,omitempty" validate:"max=200"`
+	Description string `json:"description,omitempty" validate:"max=500"`
+	Image       string `json:"image,omitempty" validate:"omitempty,url,max=2048,allowed_scheme,public_host,allowed_domain,not_self,safe_url"`
+	Fetch       bool   `json:"fetch,omitempty"`
+}
+
+// Response represents the link with its new card.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Link
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLGetter
+type URLGetter interface {
+	Get(alias string) (*storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLUpdater
+type URLUpdater interface {
+	Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=MetaFetcher
+type MetaFetcher interface {
+	Fetch(ctx context.Context, pageURL string) (*storage.Meta, error)
+}
+
+// Screener adds the checks of link destinations to a validator. See
+// screening.Screener.
+type Screener interface {
+	Register(v *validator.Validate)
+}
+
+// New handles setting the link card of a link by its alias.
+// @Summary      Set link card
+// @Description  Sets the OpenGraph/Twitter card served to link preview bots, optionally fetched from the destination page. The image URL is screened like link destinations. Send the ETag of the link in If-Match to change only an unchanged version
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias     path      string   true   "URL alias"
+// @Param        If-Match  header    string   false  "ETag of the version being changed"
+// @Param        request   body      Request  true   "Card metadata"
+// @Success      200       {object}  Response
+// @Failure      400       {object}  response.Response  "Invalid request"
+// @Failure      401       {object}  response.Response  "Unauthorized"
+// @Failure      403       {object}  response.Response  "Alias belongs to another user"
+// @Failure      404       {object}  response.Response  "Alias not found"
+// @Failure      412       {object}  response.Response  "Link was modified concurrently"
+// @Failure      500       {object}  response.Response  "Internal server error"
+// @Failure      502       {object}  response.Response  "Failed to fetch the destination page"
+// @Router       /api/url/{alias}/meta [put]
+func New(log *zap.SugaredLogger, urlGetter URLGetter, urlUpdater URLUpdater, cacheDeleter CacheDeleter, fetcher MetaFetcher, screener Screener) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		version, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Infow("invalid If-Match header", "header", r.Header.Get("If-Match"))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid If-Match header"))
+			return
+		}
+
+		var req Request
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		validate := validator.New()
+		screener.Register(validate)
+		if err = validate.StructCtx(r.Context(), req); err != nil {
+			var validateErrs validator.ValidationErrors
+			errors.As(err, &validateErrs)
+
+			log.Error("failed to validate request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidateError(validateErrs))
+			return
+		}
+		isAdmin := auth.IsAdminFromContext(r.Context())
+
+		meta := &storage.Meta{Title: req.Title, Description: req.Description, Image: req.Image}
+		if req.Fetch {
+			link, err := urlGetter.Get(alias)
+			if err != nil {
+				if errors.Is(err, storage.ErrURLNotFound) {
+					log.Infow("alias not found", "alias", alias)
+					render.Status(r, http.StatusNotFound)
+					render.JSON(w, r, resp.Error("alias not found"))
+					return
+				}
+				log.Error("failed to get url", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to get url"))
+				return
+			}
+			// Only the owner may make the service fetch the destination.
+			if !isAdmin && link.OwnerID != ownerID {
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
+			fetched, err := fetcher.Fetch(r.Context(), link.URL)
+			if err != nil {
+				log.Infow("failed to fetch metadata", "url", link.URL, "error", err)
+				render.Status(r, http.StatusBadGateway)
+				render.JSON(w, r, resp.Error("failed to fetch metadata"))
+				return
+			}
+			meta = merge(fetched, meta)
+			// A fetched image is screened like one set in the request and
+			// left out of the card if it does not pass.
+			if meta.Image != req.Image && validate.StructCtx(r.Context(), Request{Image: meta.Image}) != nil {
+				log.Infow("fetched image rejected", "image", meta.Image)
+				meta.Image = ""
+			}
+		}
+
+		link, err := urlUpdater.Update(alias, ownerID, isAdmin, version, storage.LinkUpdate{Meta: meta})
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrURLNotFound):
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+			case errors.Is(err, storage.ErrForbidden):
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+			case errors.Is(err, storage.ErrVersionConflict):
+				log.Infow("version conflict", "alias", alias, "version", version)
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, resp.Error("link was modified concurrently"))
+			default:
+				log.Error("failed to set metadata", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to set metadata"))
+			}
+			return
+		}
+		if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+			log.Error("failed to delete alias from cache", zap.Error(err))
+		}
+
+		log.Infow("metadata updated", "alias", alias, "fetched", req.Fetch)
+		w.Header().Set("ETag", etag.Format(link.Version))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     *link,
+		})
+	}
+}
+
+// Limits of the fetched metadata, matching the validation of Request.
+const (
+	maxTitle       = 200
+	maxDescription = 500
+	maxImage       = 2048
+)
+
+// merge returns the fetched metadata, cut to the limits of Request, with
+// the fields set in override replacing it.
+func merge(fetched, override *storage.Meta) *storage.Meta {
+	meta := storage.Meta{
+		Title:       truncate(fetched.Title, maxTitle),
+		Description: truncate(fetched.Description, maxDescription),
+	}
+	if len(fetched.Image) <= maxImage {
+		meta.Image = fetched.Image
+	}
+	if override.Title != "" {
+		meta.Title = override.Title
+	}
+	if override.Description != "" {
+		meta.Description = override.Description
+	}
+	if override.Image != "" {
+		meta.Image = override.Image
+	}
+	return &meta
+}
+
+// truncate cuts s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
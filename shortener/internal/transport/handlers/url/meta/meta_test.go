@@ -0,0 +1,240 @@
+package meta_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/config"
+	"linkify/internal/lib/api/etag"
+	"linkify/internal/screening"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/meta"
+	mocker "linkify/internal/transport/handlers/url/meta/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetaHandler(t *testing.T) {
+	const (
+		alias   = "alias"
+		ownerID = "42"
+		target  = "https://example.com/page"
+	)
+	card := &storage.Meta{Title: "Spring sale", Description: "Everything -20%", Image: "https://example.com/cover.png"}
+	fetched := &storage.Meta{Title: "Fetched", Description: "From the page", Image: "https://example.com/og.png"}
+	cases := []struct {
+		name         string
+		body         string
+		ifMatch      string
+		version      int
+		link         *storage.Link
+		getError     error
+		fetched      *storage.Meta
+		fetchError   error
+		update       *storage.Meta
+		expectUpdate bool
+		mockError    error
+		statusCode   int
+		respError    string
+	}{
+		{
+			name:         "Manual card",
+			body:         `{"title":"Spring sale","description":"Everything -20%","image":"https://example.com/cover.png"}`,
+			ifMatch:      etag.Format(3),
+			version:      3,
+			update:       card,
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Remove card",
+			body:         `{}`,
+			update:       &storage.Meta{},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Fetched card",
+			body:         `{"fetch":true}`,
+			link:         &storage.Link{Alias: alias, URL: target, OwnerID: ownerID},
+			fetched:      fetched,
+			update:       fetched,
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Fetched card with override",
+			body:         `{"fetch":true,"title":"Own title"}`,
+			link:         &storage.Link{Alias: alias, URL: target, OwnerID: ownerID},
+			fetched:      fetched,
+			update:       &storage.Meta{Title: "Own title", Description: fetched.Description, Image: fetched.Image},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Fetched title is truncated",
+			body:         `{"fetch":true}`,
+			link:         &storage.Link{Alias: alias, URL: target, OwnerID: ownerID},
+			fetched:      &storage.Meta{Title: strings.Repeat("я", 250)},
+			update:       &storage.Meta{Title: strings.Repeat("я", 200)},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:       "Fetch of another user's link",
+			body:       `{"fetch":true}`,
+			link:       &storage.Link{Alias: alias, URL: target, OwnerID: "7"},
+			statusCode: http.StatusForbidden,
+			respError:  "access denied",
+		},
+		{
+			name:       "Fetch of unknown alias",
+			body:       `{"fetch":true}`,
+			getError:   storage.ErrURLNotFound,
+			statusCode: http.StatusNotFound,
+			respError:  "alias not found",
+		},
+		{
+			name:       "Fetch failed",
+			body:       `{"fetch":true}`,
+			link:       &storage.Link{Alias: alias, URL: target, OwnerID: ownerID},
+			fetchError: errors.New("page is not HTML"),
+			statusCode: http.StatusBadGateway,
+			respError:  "failed to fetch metadata",
+		},
+		{
+			name:       "Invalid image",
+			body:       `{"image":"cover.png"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Image is not a valid URL",
+		},
+		{
+			name:       "Private image",
+			body:       `{"image":"http://localhost/cover.png"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Image points to a private or local address",
+		},
+		{
+			name:         "Fetched private image is dropped",
+			body:         `{"fetch":true}`,
+			link:         &storage.Link{Alias: alias, URL: target, OwnerID: ownerID},
+			fetched:      &storage.Meta{Title: "Fetched", Image: "http://169.254.169.254/latest"},
+			update:       &storage.Meta{Title: "Fetched"},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:       "Title too long",
+			body:       `{"title":"` + strings.Repeat("a", 201) + `"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Title is not valid",
+		},
+		{
+			name:       "Invalid If-Match",
+			body:       `{"title":"Spring sale"}`,
+			ifMatch:    "abc",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid If-Match header",
+		},
+		{
+			name:         "Version conflict",
+			body:         `{"title":"Spring sale"}`,
+			ifMatch:      etag.Format(2),
+			version:      2,
+			update:       &storage.Meta{Title: "Spring sale"},
+			expectUpdate: true,
+			mockError:    storage.ErrVersionConflict,
+			statusCode:   http.StatusPreconditionFailed,
+			respError:    "link was modified concurrently",
+		},
+		{
+			name:         "Not owner",
+			body:         `{"title":"Spring sale"}`,
+			update:       &storage.Meta{Title: "Spring sale"},
+			expectUpdate: true,
+			mockError:    storage.ErrForbidden,
+			statusCode:   http.StatusForbidden,
+			respError:    "access denied",
+		},
+		{
+			name:         "Storage error",
+			body:         `{"title":"Spring sale"}`,
+			update:       &storage.Meta{Title: "Spring sale"},
+			expectUpdate: true,
+			mockError:    errors.New("unexpected error"),
+			statusCode:   http.StatusInternalServerError,
+			respError:    "failed to set metadata",
+		},
+	}
+	screener, err := screening.New(config.Screening{BlockPrivate: true}, zapdiscard.New())
+	require.NoError(t, err)
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocker.NewURLGetter(t)
+			urlUpdaterMock := mocker.NewURLUpdater(t)
+			cacheDeleterMock := mocker.NewCacheDeleter(t)
+			metaFetcherMock := mocker.NewMetaFetcher(t)
+			if tc.link != nil || tc.getError != nil {
+				urlGetterMock.On("Get", alias).Return(tc.link, tc.getError).Once()
+			}
+			if tc.fetched != nil || tc.fetchError != nil {
+				metaFetcherMock.On("Fetch", mock.Anything, target).Return(tc.fetched, tc.fetchError).Once()
+			}
+			if tc.expectUpdate {
+				var result *storage.Link
+				if tc.mockError == nil {
+					result = &storage.Link{
+						Alias:   alias,
+						URL:     target,
+						OwnerID: ownerID,
+						Version: tc.version + 1,
+						Meta:    tc.update,
+					}
+					cacheDeleterMock.On("Delete", mock.Anything, alias).
+						Return(nil).
+						Once()
+				}
+				urlUpdaterMock.On("Update", alias, ownerID, false, tc.version, storage.LinkUpdate{Meta: tc.update}).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := meta.New(zapdiscard.New(), urlGetterMock, urlUpdaterMock, cacheDeleterMock, metaFetcherMock, screener)
+			req, err := http.NewRequest(http.MethodPut, "/url/"+alias+"/meta", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, ownerID, "user@example.com", false))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp meta.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, etag.Format(tc.version+1), rr.Header().Get("ETag"))
+				require.Equal(t, tc.update, resp.Meta)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package qr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	qrimage "linkify/internal/lib/qr"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSize   = 256
+	minSize       = 64
+	maxSize       = 2048
+	defaultMargin = 4
+	maxMargin     = 16
+	// cacheMaxAge is how long clients may reuse a code; it depends only on
+	// the alias and the parameters, so it never changes.
+	cacheMaxAge = 24 * 60 * 60
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLGetter
+type URLGetter interface {
+	Get(alias string) (*storage.Link, error)
+}
+
+// New handles rendering the QR code of a short link. baseURL is prepended
+// to the alias to build the encoded URL.
+// @Summary      QR code of URL
+// @Description  Returns a PNG or SVG QR code of the short URL. Only the owner or an admin may request it
+// @Tags         url
+// @Produce      png
+// @Produce      image/svg+xml
+// @Security     ApiKeyAuth
+// @Param        alias   path      string  true   "URL alias"
+// @Param        format  query     string  false  "Image format: png (default) or svg"
+// @Param        size    query     int     false  "Width and height in pixels (64-2048, default 256)"
+// @Param        level   query     string  false  "Error-correction level: L, M (default), Q or H"
+// @Param        margin  query     int     false  "Quiet zone in modules (0-16, default 4)"
+// @Param        fg      query     string  false  "Foreground colour as hex RGB (default 000000)"
+// @Param        bg      query     string  false  "Background colour as hex RGB (default ffffff)"
+// @Success      200     {file}    file
+// @Success      304     "Not Modified"
+// @Failure      400     {object}  response.Response  "Invalid request"
+// @Failure      401     {object}  response.Response  "Unauthorized"
+// @Failure      403     {object}  response.Response  "Alias belongs to another user"
+// @Failure      404     {object}  response.Response  "Alias not found"
+// @Failure      500     {object}  response.Response  "Internal server error"
+// @Router       /api/url/{alias}/qr [get]
+func New(log *zap.SugaredLogger, urlGetter URLGetter, baseURL string) http.HandlerFunc {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		format, opts, err := parseParams(r.URL.Query())
+		if err != nil {
+			log.Infow("invalid qr request", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		link, err := urlGetter.Get(alias)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+				return
+			}
+			log.Error("failed to get url", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+		if link.OwnerID != ownerID && !auth.IsAdminFromContext(r.Context()) {
+			log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("access denied"))
+			return
+		}
+
+		content := baseURL + "/" + link.Alias
+		etag := entityTag(content, format, opts)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", cacheMaxAge))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		var image []byte
+		if format == "svg" {
+			w.Header().Set("Content-Type", "image/svg+xml")
+			image, err = qrimage.SVG(content, opts)
+		} else {
+			w.Header().Set("Content-Type", "image/png")
+			image, err = qrimage.PNG(content, opts)
+		}
+		if err != nil {
+			w.Header().Del("ETag")
+			w.Header().Del("Cache-Control")
+			log.Error("failed to render qr code", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to render qr code"))
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+		w.WriteHeader(http.StatusOK)
+		if _, err = w.Write(image); err != nil {
+			log.Error("failed to write qr code", zap.Error(err))
+		}
+	}
+}
+
+func parseParams(query url.Values) (string, qrimage.Options, error) {
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = "png"
+	case "png", "svg":
+	default:
+		return "", qrimage.Options{}, errors.New("format must be png or svg")
+	}
+
+	opts := qrimage.Options{Size: defaultSize, Margin: defaultMargin}
+	var err error
+	if v := query.Get("size"); v != "" {
+		if opts.Size, err = strconv.Atoi(v); err != nil || opts.Size < minSize || opts.Size > maxSize {
+			return "", opts, fmt.Errorf("size must be between %d and %d", minSize, maxSize)
+		}
+	}
+	if v := query.Get("margin"); v != "" {
+		if opts.Margin, err = strconv.Atoi(v); err != nil || opts.Margin < 0 || opts.Margin > maxMargin {
+			return "", opts, fmt.Errorf("margin must be between 0 and %d", maxMargin)
+		}
+	}
+	if opts.Level, err = qrimage.ParseLevel(valueOr(query.Get("level"), "M")); err != nil {
+		return "", opts, err
+	}
+	if opts.Foreground, err = qrimage.ParseColor(valueOr(query.Get("fg"), "000000")); err != nil {
+		return "", opts, fmt.Errorf("fg: %w", err)
+	}
+	if opts.Background, err = qrimage.ParseColor(valueOr(query.Get("bg"), "ffffff")); err != nil {
+		return "", opts, fmt.Errorf("bg: %w", err)
+	}
+	return format, opts, nil
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// entityTag identifies the rendered image, which depends only on the
+// encoded URL and the rendering parameters.
+func entityTag(content, format string, opts qrimage.Options) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%+v", content, format, opts)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
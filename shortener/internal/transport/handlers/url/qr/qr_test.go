@@ -0,0 +1,149 @@
+package qr_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/qr"
+	mocker "linkify/internal/transport/handlers/url/qr/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQRHandler(t *testing.T) {
+	const (
+		alias   = "H2vga5"
+		ownerID = "42"
+	)
+	cases := []struct {
+		name        string
+		query       string
+		linkOwner   string
+		mockError   error
+		expectGet   bool
+		statusCode  int
+		contentType string
+		respError   string
+	}{
+		{
+			name:        "PNG",
+			linkOwner:   ownerID,
+			expectGet:   true,
+			statusCode:  http.StatusOK,
+			contentType: "image/png",
+		},
+		{
+			name:        "SVG",
+			query:       "?format=svg&size=512&level=H&margin=2&fg=%23112233&bg=eee",
+			linkOwner:   ownerID,
+			expectGet:   true,
+			statusCode:  http.StatusOK,
+			contentType: "image/svg+xml",
+		},
+		{
+			name:       "Invalid size",
+			query:      "?size=10",
+			statusCode: http.StatusBadRequest,
+			respError:  "size must be between 64 and 2048",
+		},
+		{
+			name:       "Invalid level",
+			query:      "?level=X",
+			statusCode: http.StatusBadRequest,
+			respError:  "level must be one of L, M, Q, H",
+		},
+		{
+			name:       "Invalid colour",
+			query:      "?fg=black",
+			statusCode: http.StatusBadRequest,
+			respError:  "fg: color must be a hex RGB value",
+		},
+		{
+			name:       "Not owner",
+			linkOwner:  "7",
+			expectGet:  true,
+			statusCode: http.StatusForbidden,
+			respError:  "access denied",
+		},
+		{
+			name:       "Not found",
+			mockError:  storage.ErrURLNotFound,
+			expectGet:  true,
+			statusCode: http.StatusNotFound,
+			respError:  "alias not found",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocker.NewURLGetter(t)
+			if tc.expectGet {
+				var link *storage.Link
+				if tc.mockError == nil {
+					link = &storage.Link{Alias: alias, URL: "https://example.com", OwnerID: tc.linkOwner}
+				}
+				urlGetterMock.On("Get", alias).
+					Return(link, tc.mockError).
+					Once()
+			}
+
+			handler := qr.New(zapdiscard.New(), urlGetterMock, "https://lnk.example/")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, newRequest(t, alias, tc.query))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			if tc.respError != "" {
+				var body resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				require.Equal(t, tc.respError, body.Error)
+				return
+			}
+			require.Equal(t, tc.contentType, rr.Header().Get("Content-Type"))
+			require.NotEmpty(t, rr.Header().Get("ETag"))
+			require.Equal(t, "private, max-age=86400", rr.Header().Get("Cache-Control"))
+			require.NotEmpty(t, rr.Body.Bytes())
+		})
+	}
+}
+
+func TestQRHandlerNotModified(t *testing.T) {
+	const alias = "H2vga5"
+	urlGetterMock := mocker.NewURLGetter(t)
+	urlGetterMock.On("Get", alias).
+		Return(&storage.Link{Alias: alias, OwnerID: "42"}, nil).
+		Twice()
+	handler := qr.New(zapdiscard.New(), urlGetterMock, "https://lnk.example")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newRequest(t, alias, "?format=svg"))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, strings.HasPrefix(rr.Body.String(), "<svg"))
+	etag := rr.Header().Get("ETag")
+
+	req := newRequest(t, alias, "?format=svg")
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.Bytes())
+}
+
+func newRequest(t *testing.T, alias, query string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, "/url/"+alias+"/qr"+query, nil)
+	require.NoError(t, err)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("alias", alias)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	return req.WithContext(auth.WithUser(ctx, "42", "user@example.com", false))
+}
@@ -0,0 +1,43 @@
+package redirect_test
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectDisabledLink(t *testing.T) {
+	const alias = "taken-down"
+	link := &storage.Link{Alias: alias, URL: "https://phish.example/login", Disabled: true, MaxClicks: 3}
+	handlers := map[string]func(*mocker.URLGetter, *mocker.CacheGetter) http.Handler{
+		"Redirect": func(urlGetter *mocker.URLGetter, cacheGetter *mocker.CacheGetter) http.Handler {
+			return redirect.New(zapdiscard.New(), urlGetter, cacheGetter, mocker.NewMetricsGetter(t), mocker.NewClickTracker(t), redirect.Config{})
+		},
+		"Preview": func(urlGetter *mocker.URLGetter, cacheGetter *mocker.CacheGetter) http.Handler {
+			return redirect.NewPreview(zapdiscard.New(), urlGetter, cacheGetter)
+		},
+	}
+	for name, newHandler := range handlers {
+		newHandler := newHandler
+		t.Run(name, func(t *testing.T) {
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(nil, storage.ErrAliasNotFound).Once()
+			urlGetterMock.On("Get", alias).Return(link, nil).Once()
+
+			rr := httptest.NewRecorder()
+			newHandler(urlGetterMock, cacheGetterMock).ServeHTTP(rr, previewRequest(t, alias, "/"+alias))
+
+			require.Equal(t, http.StatusUnavailableForLegalReasons, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			require.Contains(t, rr.Body.String(), "This link has been disabled")
+			require.NotContains(t, rr.Body.String(), link.URL)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectLimitedLink(t *testing.T) {
+	const (
+		alias  = "once"
+		target = "https://example.com/secret"
+	)
+	intPtr := func(v int) *int { return &v }
+	cases := []struct {
+		name         string
+		cached       bool
+		clicksLeft   *int
+		consumeError error
+		evict        bool
+		statusCode   int
+	}{
+		{
+			name:       "Clicks left",
+			cached:     true,
+			clicksLeft: intPtr(2),
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Last click",
+			clicksLeft: intPtr(0),
+			evict:      true,
+			statusCode: http.StatusFound,
+		},
+		{
+			name:         "Exhausted",
+			cached:       true,
+			consumeError: storage.ErrLinkExhausted,
+			evict:        true,
+			statusCode:   http.StatusGone,
+		},
+		{
+			name:         "Deleted meanwhile",
+			consumeError: storage.ErrURLNotFound,
+			statusCode:   http.StatusNotFound,
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := &storage.Link{Alias: alias, URL: target, MaxClicks: 3}
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			if tc.cached {
+				cacheGetterMock.On("Get", mock.Anything, alias).Return(link, nil).Once()
+			} else {
+				cacheGetterMock.On("Get", mock.Anything, alias).Return(nil, storage.ErrAliasNotFound).Once()
+				urlGetterMock.On("Get", alias).Return(link, nil).Once()
+			}
+			var consumed *storage.Link
+			if tc.consumeError == nil {
+				consumed = &storage.Link{Alias: alias, URL: target, MaxClicks: 3, ClicksLeft: tc.clicksLeft}
+			}
+			urlGetterMock.On("ConsumeClick", alias).Return(consumed, tc.consumeError).Once()
+			if tc.evict {
+				cacheGetterMock.On("Delete", mock.Anything, alias).Return(nil).Once()
+			}
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			clickTrackerMock := mocker.NewClickTracker(t)
+			if tc.statusCode == http.StatusFound {
+				metricsGetterMock.On("IncLinksRedirected").Once()
+				clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Once()
+			}
+
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, redirect.Config{})
+			req, err := http.NewRequest(http.MethodGet, "/"+alias, nil)
+			require.NoError(t, err)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+		})
+	}
+}
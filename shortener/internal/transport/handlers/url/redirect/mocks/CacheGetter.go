@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	storage "linkify/internal/storage"
 )
 
 // CacheGetter is an autogenerated mock type for the CacheGetter type
@@ -14,22 +16,24 @@ type CacheGetter struct {
 }
 
 // Get provides a mock function with given fields: ctx, key
-func (_m *CacheGetter) Get(ctx context.Context, key string) (string, error) {
+func (_m *CacheGetter) Get(ctx context.Context, key string) (*storage.Link, error) {
 	ret := _m.Called(ctx, key)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Get")
 	}
 
-	var r0 string
+	var r0 *storage.Link
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*storage.Link, error)); ok {
 		return rf(ctx, key)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *storage.Link); ok {
 		r0 = rf(ctx, key)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.Link)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
@@ -41,6 +45,24 @@ func (_m *CacheGetter) Get(ctx context.Context, key string) (string, error) {
 	return r0, r1
 }
 
+// Delete provides a mock function with given fields: ctx, key
+func (_m *CacheGetter) Delete(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewCacheGetter creates a new instance of CacheGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCacheGetter(t interface {
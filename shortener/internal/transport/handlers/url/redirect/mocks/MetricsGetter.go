@@ -14,6 +14,11 @@ func (_m *MetricsGetter) IncLinksRedirected() {
 	_m.Called()
 }
 
+// IncVariantServed provides a mock function with given fields: variant
+func (_m *MetricsGetter) IncVariantServed(variant string) {
+	_m.Called(variant)
+}
+
 // NewMetricsGetter creates a new instance of MetricsGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMetricsGetter(t interface {
@@ -2,7 +2,11 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	storage "linkify/internal/storage"
+)
 
 // URLGetter is an autogenerated mock type for the URLGetter type
 type URLGetter struct {
@@ -10,22 +14,54 @@ type URLGetter struct {
 }
 
 // Get provides a mock function with given fields: alias
-func (_m *URLGetter) Get(alias string) (string, error) {
+func (_m *URLGetter) Get(alias string) (*storage.Link, error) {
 	ret := _m.Called(alias)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Get")
 	}
 
-	var r0 string
+	var r0 *storage.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*storage.Link, error)); ok {
+		return rf(alias)
+	}
+	if rf, ok := ret.Get(0).(func(string) *storage.Link); ok {
+		r0 = rf(alias)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeClick provides a mock function with given fields: alias
+func (_m *URLGetter) ConsumeClick(alias string) (*storage.Link, error) {
+	ret := _m.Called(alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeClick")
+	}
+
+	var r0 *storage.Link
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(string) (*storage.Link, error)); ok {
 		return rf(alias)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
+	if rf, ok := ret.Get(0).(func(string) *storage.Link); ok {
 		r0 = rf(alias)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*storage.Link)
+		}
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
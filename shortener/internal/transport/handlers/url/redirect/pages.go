@@ -0,0 +1,124 @@
+package redirect
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"html/template"
+	"linkify/internal/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+var (
+	passwordPage = template.Must(template.ParseFS(templates, "templates/password.html"))
+	pendingPage  = template.Must(template.ParseFS(templates, "templates/pending.html"))
+	previewPage  = template.Must(template.ParseFS(templates, "templates/preview.html"))
+	unfurlPage   = template.Must(template.ParseFS(templates, "templates/unfurl.html"))
+	disabledPage = template.Must(template.ParseFS(templates, "templates/disabled.html"))
+)
+
+// accessCookiePrefix is followed by the alias in the name of the cookie
+// that lets a visitor skip the password prompt.
+const accessCookiePrefix = "link_access_"
+
+// hasAccess reports whether the request carries a valid access cookie
+// for the link.
+func hasAccess(r *http.Request, link *storage.Link, secret []byte, now time.Time) bool {
+	cookie, err := r.Cookie(accessCookiePrefix + link.Alias)
+	if err != nil {
+		return false
+	}
+	expires, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() >= unix {
+		return false
+	}
+	expected := accessSignature(link, unix, secret)
+	given, err := base64.RawURLEncoding.DecodeString(signature)
+	return err == nil && hmac.Equal(given, expected)
+}
+
+// setAccessCookie lets the visitor open the link without the password
+// until ttl elapses.
+func setAccessCookie(w http.ResponseWriter, r *http.Request, link *storage.Link, secret []byte, ttl time.Duration, now time.Time) {
+	expires := now.Add(ttl)
+	signature := accessSignature(link, expires.Unix(), secret)
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessCookiePrefix + link.Alias,
+		Value:    strconv.FormatInt(expires.Unix(), 10) + "." + base64.RawURLEncoding.EncodeToString(signature),
+		Path:     "/" + link.Alias,
+		Expires:  expires,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// accessSignature covers the password hash, so changing the password
+// invalidates issued cookies.
+func accessSignature(link *storage.Link, expires int64, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(link.Alias))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	mac.Write([]byte{0})
+	mac.Write(link.PasswordHash)
+	return mac.Sum(nil)
+}
+
+func renderPasswordPage(w http.ResponseWriter, r *http.Request, status int, alias, errMsg string) error {
+	// The form posts to the same query string so that it can be forwarded.
+	action := "/" + alias
+	if r.URL.RawQuery != "" {
+		action += "?" + r.URL.RawQuery
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	return passwordPage.Execute(w, struct {
+		Action string
+		Error  string
+	}{Action: action, Error: errMsg})
+}
+
+// renderPendingPage responds with 404 like for an unknown alias, but tells
+// the visitor when the link opens.
+func renderPendingPage(w http.ResponseWriter, activeFrom time.Time) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusNotFound)
+	return pendingPage.Execute(w, struct {
+		ActiveFrom time.Time
+	}{ActiveFrom: activeFrom.UTC()})
+}
+
+// renderDisabledPage tells the visitor that the link was taken down.
+func renderDisabledPage(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusUnavailableForLegalReasons)
+	return disabledPage.Execute(w, nil)
+}
+
+// renderUnfurlPage serves the metadata of the link to a link preview bot.
+// The page leaves out the destination, which anyone faking the user agent
+// of a bot could read otherwise.
+func renderUnfurlPage(w http.ResponseWriter, link *storage.Link) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	return unfurlPage.Execute(w, struct {
+		Meta *storage.Meta
+	}{Meta: link.Meta})
+}
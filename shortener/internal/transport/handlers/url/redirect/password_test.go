@@ -0,0 +1,111 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedirectProtectedLink(t *testing.T) {
+	const (
+		alias    = "secret"
+		target   = "https://example.com/internal"
+		password = "hunter22"
+	)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	stored := &storage.Link{Alias: alias, URL: target, Protected: true, PasswordHash: hash}
+	// The cache keeps only the flag, never the hash.
+	cached := &storage.Link{Alias: alias, URL: target, Protected: true}
+	cfg := redirect.Config{CookieSecret: []byte("secret"), CookieTTL: time.Hour}
+
+	newHandler := func(t *testing.T, redirects int) http.HandlerFunc {
+		urlGetterMock := mocker.NewURLGetter(t)
+		urlGetterMock.On("Get", alias).Return(stored, nil).Once()
+		cacheGetterMock := mocker.NewCacheGetter(t)
+		cacheGetterMock.On("Get", mock.Anything, alias).Return(cached, nil).Once()
+		metricsGetterMock := mocker.NewMetricsGetter(t)
+		clickTrackerMock := mocker.NewClickTracker(t)
+		if redirects > 0 {
+			metricsGetterMock.On("IncLinksRedirected").Times(redirects)
+			clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Times(redirects)
+		}
+		return redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, cfg)
+	}
+	newRequest := func(t *testing.T, method string, body io.Reader) *http.Request {
+		req, err := http.NewRequest(method, "/"+alias, body)
+		require.NoError(t, err)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", alias)
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+	form := func(password string) io.Reader {
+		return strings.NewReader(url.Values{"password": {password}}.Encode())
+	}
+
+	t.Run("Form", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newHandler(t, 0).ServeHTTP(rr, newRequest(t, http.MethodGet, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+		require.Contains(t, rr.Body.String(), `action="/secret"`)
+		require.NotContains(t, rr.Body.String(), target)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newHandler(t, 0).ServeHTTP(rr, newRequest(t, http.MethodPost, form("wrong")))
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.Contains(t, rr.Body.String(), "Wrong password")
+		require.Empty(t, rr.Result().Cookies())
+	})
+
+	t.Run("Correct password and cookie", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newHandler(t, 1).ServeHTTP(rr, newRequest(t, http.MethodPost, form(password)))
+
+		require.Equal(t, http.StatusSeeOther, rr.Code)
+		require.Equal(t, target, rr.Header().Get("Location"))
+		cookies := rr.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.True(t, cookies[0].HttpOnly)
+		require.Equal(t, "/"+alias, cookies[0].Path)
+
+		req := newRequest(t, http.MethodGet, nil)
+		req.AddCookie(cookies[0])
+		rr = httptest.NewRecorder()
+		newHandler(t, 1).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusFound, rr.Code)
+		require.Equal(t, target, rr.Header().Get("Location"))
+	})
+
+	t.Run("Forged cookie", func(t *testing.T) {
+		req := newRequest(t, http.MethodGet, nil)
+		req.AddCookie(&http.Cookie{Name: "link_access_" + alias, Value: strings.Repeat("9", 10) + ".c2lnbmF0dXJl"})
+		req.AddCookie(&http.Cookie{Name: "link_access_other", Value: "1"})
+		rr := httptest.NewRecorder()
+		newHandler(t, 0).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Body.String(), "password")
+	})
+}
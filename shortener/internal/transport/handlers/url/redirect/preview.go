@@ -0,0 +1,147 @@
+package redirect
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// previewParam set to 1 shows the preview page of any link.
+	previewParam = "preview"
+	// confirmParam set to 1 skips the preview page of a link that always
+	// shows it; the Continue button of the page adds it.
+	confirmParam = "confirm"
+)
+
+// NewPreview handles the preview page of a link requested as its alias
+// followed by "+".
+// @Summary      Preview URL
+// @Description  Shows the destination, creation date and title of a link without following it
+// @Tags         url
+// @Produce      html
+// @Produce      json
+// @Param        alias  path  string  true  "URL alias"
+// @Success      200    "Preview page"
+// @Failure      404    {object}  response.Response  "Alias not found or not active yet"
+// @Failure      410    {object}  response.Response  "Link expired or no longer active"
+// @Failure      451    "Link disabled by an admin"
+// @Failure      500    {object}  response.Response  "Internal server error"
+// @Router       /{alias}+ [get]
+func NewPreview(log *zap.SugaredLogger, urlGetter URLGetter, cacheGetter CacheGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		link, err := lookup(r.Context(), log, alias, urlGetter, cacheGetter)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Infow("url not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("url not found"))
+				return
+			}
+			log.Error("failed to get url", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+
+		if link.Disabled {
+			log.Infow("link disabled", "alias", alias)
+			if err = renderDisabledPage(w); err != nil {
+				log.Error("failed to render disabled page", zap.Error(err))
+			}
+			return
+		}
+
+		now := time.Now()
+		if link.IsExpired(now) {
+			log.Infow("link expired", "alias", alias, "expires_at", link.ExpiresAt)
+			render.Status(r, http.StatusGone)
+			render.JSON(w, r, resp.Error("link expired"))
+			return
+		}
+		// The destination of a scheduled link stays hidden until it opens.
+		if link.IsPending(now) {
+			log.Infow("link not active yet", "alias", alias, "active_from", link.ActiveFrom)
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("url not found"))
+			return
+		}
+		if link.IsInactive(now) {
+			log.Infow("link no longer active", "alias", alias, "active_until", link.ActiveUntil)
+			render.Status(r, http.StatusGone)
+			render.JSON(w, r, resp.Error("link is no longer active"))
+			return
+		}
+		if err = renderPreviewPage(w, r, link); err != nil {
+			log.Error("failed to render preview page", zap.Error(err))
+		}
+	}
+}
+
+// wantsPreview reports whether the preview page is shown instead of
+// redirecting.
+func wantsPreview(r *http.Request, link *storage.Link) bool {
+	query := r.URL.Query()
+	if query.Get(previewParam) == "1" {
+		return true
+	}
+	return link.Interstitial && query.Get(confirmParam) != "1"
+}
+
+// renderPreviewPage shows where the link goes. Its Continue button follows
+// the link with the query of the request, so forwarded parameters survive.
+// The destination of a protected link is not shown, nor that of a limited
+// link, whose clicks would be bypassed by reading it here.
+func renderPreviewPage(w http.ResponseWriter, r *http.Request, link *storage.Link) error {
+	query := r.URL.Query()
+	query.Del(previewParam)
+	query.Set(confirmParam, "1")
+	continueURL := url.URL{Path: "/" + link.Alias, RawQuery: query.Encode()}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	return previewPage.Execute(w, struct {
+		Title       string
+		Destination string
+		CreatedAt   time.Time
+		Hidden      string
+		ContinueURL string
+	}{
+		Title:       link.Title,
+		Destination: link.URL,
+		CreatedAt:   link.CreatedAt.UTC(),
+		Hidden:      hiddenReason(link),
+		ContinueURL: continueURL.String(),
+	})
+}
+
+// hiddenReason explains why the preview page hides the destination of
+// link, or is empty when it shows it.
+func hiddenReason(link *storage.Link) string {
+	switch {
+	case link.Protected:
+		return "Hidden until the password is entered"
+	case link.IsLimited():
+		return "Hidden until the link is followed"
+	}
+	return ""
+}
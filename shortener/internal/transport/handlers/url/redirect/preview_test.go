@@ -0,0 +1,184 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const previewTarget = "https://example.com/landing"
+
+func previewRequest(t *testing.T, alias, path string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("alias", alias)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestPreviewHandler(t *testing.T) {
+	const alias = "look"
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	cases := []struct {
+		name       string
+		link       *storage.Link
+		mockError  error
+		statusCode int
+		contains   []string
+		excludes   []string
+	}{
+		{
+			name: "Preview",
+			link: &storage.Link{
+				Alias:     alias,
+				URL:       previewTarget,
+				Title:     "Spring <sale>",
+				CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			},
+			statusCode: http.StatusOK,
+			contains:   []string{previewTarget, "Spring &lt;sale&gt;", "1 Mar 2025", `href="/look?confirm=1"`},
+		},
+		{
+			name:       "Protected",
+			link:       &storage.Link{Alias: alias, URL: previewTarget, Protected: true},
+			statusCode: http.StatusOK,
+			contains:   []string{"Hidden until the password is entered"},
+			excludes:   []string{previewTarget},
+		},
+		{
+			name:       "Limited",
+			link:       &storage.Link{Alias: alias, URL: previewTarget, MaxClicks: 1},
+			statusCode: http.StatusOK,
+			contains:   []string{"Hidden until the link is followed"},
+			excludes:   []string{previewTarget},
+		},
+		{
+			name:       "Expired",
+			link:       &storage.Link{Alias: alias, URL: previewTarget, ExpiresAt: &past},
+			statusCode: http.StatusGone,
+		},
+		{
+			name:       "Not active yet",
+			link:       &storage.Link{Alias: alias, URL: previewTarget, ActiveFrom: &future},
+			statusCode: http.StatusNotFound,
+			excludes:   []string{previewTarget},
+		},
+		{
+			name:       "Not found",
+			mockError:  storage.ErrURLNotFound,
+			statusCode: http.StatusNotFound,
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(nil, storage.ErrAliasNotFound).Once()
+			urlGetterMock.On("Get", alias).Return(tc.link, tc.mockError).Once()
+
+			handler := redirect.NewPreview(zapdiscard.New(), urlGetterMock, cacheGetterMock)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, previewRequest(t, alias, "/"+alias+"+"))
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			for _, s := range tc.contains {
+				require.Contains(t, rr.Body.String(), s)
+			}
+			for _, s := range tc.excludes {
+				require.NotContains(t, rr.Body.String(), s)
+			}
+		})
+	}
+}
+
+func TestRedirectPreview(t *testing.T) {
+	const alias = "look"
+	cases := []struct {
+		name         string
+		interstitial bool
+		maxClicks    int
+		query        string
+		preview      bool
+		continueURL  string
+	}{
+		{
+			name:  "Plain link",
+			query: "?confirm=1",
+		},
+		{
+			name:        "Preview requested",
+			query:       "?preview=1&ref=tg",
+			preview:     true,
+			continueURL: `href="/look?confirm=1&amp;ref=tg"`,
+		},
+		{
+			name:         "Interstitial",
+			interstitial: true,
+			preview:      true,
+			continueURL:  `href="/look?confirm=1"`,
+		},
+		{
+			name:        "Limited link preview requested",
+			maxClicks:   1,
+			query:       "?preview=1",
+			preview:     true,
+			continueURL: `href="/look?confirm=1"`,
+		},
+		{
+			name:         "Interstitial confirmed",
+			interstitial: true,
+			query:        "?confirm=1",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := &storage.Link{Alias: alias, URL: previewTarget, Interstitial: tc.interstitial, MaxClicks: tc.maxClicks, ForwardQuery: true}
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(link, nil).Once()
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			clickTrackerMock := mocker.NewClickTracker(t)
+			if !tc.preview {
+				metricsGetterMock.On("IncLinksRedirected").Once()
+				clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Once()
+			}
+
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, redirect.Config{})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, previewRequest(t, alias, "/"+alias+tc.query))
+
+			if !tc.preview {
+				require.Equal(t, http.StatusFound, rr.Code)
+				// The confirmation is not forwarded to the destination.
+				require.Equal(t, previewTarget, rr.Header().Get("Location"))
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			require.Contains(t, rr.Body.String(), tc.continueURL)
+			if tc.maxClicks > 0 {
+				// Reading the destination must not bypass the click limit.
+				require.NotContains(t, rr.Body.String(), previewTarget)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectQuery(t *testing.T) {
+	const alias = "promo"
+	cases := []struct {
+		name     string
+		forward  bool
+		utm      *storage.UTM
+		query    string
+		location string
+	}{
+		{
+			name:     "Query dropped",
+			query:    "?ref=tg",
+			location: "https://example.com/page?id=7#faq",
+		},
+		{
+			name:     "Query forwarded",
+			forward:  true,
+			query:    "?ref=tg&id=8",
+			location: "https://example.com/page?id=8&ref=tg#faq",
+		},
+		{
+			name:     "UTM added",
+			utm:      &storage.UTM{Source: "qr", Campaign: "launch"},
+			query:    "?utm_source=ignored",
+			location: "https://example.com/page?id=7&utm_source=qr&utm_campaign=launch#faq",
+		},
+		{
+			name:     "Forwarded UTM wins",
+			forward:  true,
+			utm:      &storage.UTM{Source: "qr", Campaign: "launch"},
+			query:    "?utm_source=poster",
+			location: "https://example.com/page?id=7&utm_source=poster&utm_campaign=launch#faq",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := &storage.Link{Alias: alias, URL: "https://example.com/page?id=7#faq", ForwardQuery: tc.forward, UTM: tc.utm}
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(link, nil).Once()
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			metricsGetterMock.On("IncLinksRedirected").Once()
+			clickTrackerMock := mocker.NewClickTracker(t)
+			clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Once()
+
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, redirect.Config{})
+			req, err := http.NewRequest(http.MethodGet, "/"+alias+tc.query, nil)
+			require.NoError(t, err)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tc.location, rr.Header().Get("Location"))
+		})
+	}
+}
@@ -7,39 +7,93 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 	resp "linkify/internal/lib/api/response"
+	"linkify/internal/lib/clientip"
+	"linkify/internal/lib/destination"
+	"linkify/internal/lib/targeting"
+	"linkify/internal/lib/unfurl"
 	"linkify/internal/storage"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLGetter
 type URLGetter interface {
-	Get(alias string) (string, error)
+	Get(alias string) (*storage.Link, error)
+	ConsumeClick(alias string) (*storage.Link, error)
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheGetter
 type CacheGetter interface {
-	Get(ctx context.Context, key string) (string, error)
+	Get(ctx context.Context, key string) (*storage.Link, error)
+	Delete(ctx context.Context, key string) error
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=MetricsGetter
 type MetricsGetter interface {
 	IncLinksRedirected()
+	IncVariantServed(variant string)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=ClickTracker
+type ClickTracker interface {
+	Track(click storage.Click)
+}
+
+// Config holds the settings of password-protected, scheduled and
+// targeted links.
+type Config struct {
+	// CookieSecret signs the cookies that remember a correct password.
+	CookieSecret []byte
+	// CookieTTL is how long a visitor may skip the password prompt.
+	CookieTTL time.Duration
+	// PendingPage shows an HTML page for links whose activation window
+	// has not opened yet instead of a plain 404 response.
+	PendingPage bool
+	// Countries resolves visitor countries for routing rules. Without it
+	// rules with a country condition never match.
+	Countries targeting.CountryResolver
+	// VariantCookieTTL is how long a visitor stays on the variant of a
+	// sticky split link.
+	VariantCookieTTL time.Duration
+	// DefaultStatus is the redirect status of links without their own
+	// redirect type.
+	DefaultStatus int
+	// PermanentCacheControl is sent with 301 and 308 redirects, which
+	// browsers otherwise cache for good and keep following after the link
+	// is edited.
+	PermanentCacheControl string
 }
 
 // New handles the redirect of a alias by its url.
+// Password-protected links show a password form instead and redirect once
+// the form is submitted with the correct password. With ?preview=1, or for
+// links that always show it, a preview page is shown first.
 // @Summary      Redirect to URL
-// @Description  Redirects to the original URL using the provided alias
+// @Description  Redirects to the original URL using the provided alias. Protected links respond with a password form
 // @Tags         url
-// @Accept       json
+// @Accept       x-www-form-urlencoded
 // @Produce      json
-// @Param        alias   path      string  true  "URL alias"
+// @Produce      html
+// @Param        alias     path      string  true   "URL alias"
+// @Param        password  formData  string  false  "Password of a protected link"
+// @Param        preview   query     int     false  "1 shows the preview page instead of redirecting"
+// @Success      200     "Password form of a protected link or preview page"
+// @Success      301     "Moved Permanently, for links with redirect_type 301"
 // @Success      302     "Found"  "Redirects to the original URL"
+// @Success      307     "Temporary Redirect, for links with redirect_type 307"
+// @Success      308     "Permanent Redirect, for links with redirect_type 308"
 // @Failure      400     {object}  response.Response  "Invalid request"
-// @Failure      404     {object}  response.Response  "Alias not found"
+// @Failure      401     "Wrong password"
+// @Failure      404     {object}  response.Response  "Alias not found or not active yet"
+// @Failure      410     {object}  response.Response  "Link expired, no longer active or click limit reached"
+// @Failure      451     "Link disabled by an admin"
 // @Failure      500     {object}  response.Response  "Internal server error"
 // @Router       /{alias} [get]
-func New(log *zap.SugaredLogger, urlGetter URLGetter, cacheGetter CacheGetter, m MetricsGetter) http.HandlerFunc {
+// @Router       /{alias} [post]
+func New(log *zap.SugaredLogger, urlGetter URLGetter, cacheGetter CacheGetter, m MetricsGetter, tracker ClickTracker, cfg Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log := log.With(
 			"request_id", middleware.GetReqID(r.Context()),
@@ -53,15 +107,7 @@ func New(log *zap.SugaredLogger, urlGetter URLGetter, cacheGetter CacheGetter, m
 			return
 		}
 
-		url, err := cacheGetter.Get(r.Context(), alias)
-		if err == nil {
-			log.Infow("got url from cache", "url", url)
-			m.IncLinksRedirected()
-			http.Redirect(w, r, url, http.StatusFound)
-			return
-		}
-
-		url, err = urlGetter.Get(alias)
+		link, err := lookup(r.Context(), log, alias, urlGetter, cacheGetter)
 		if err != nil {
 			if errors.Is(err, storage.ErrURLNotFound) {
 				log.Infow("url not found", "alias", alias)
@@ -75,8 +121,208 @@ func New(log *zap.SugaredLogger, urlGetter URLGetter, cacheGetter CacheGetter, m
 			return
 		}
 
-		log.Infow("got url", "url", url)
+		if link.Disabled {
+			log.Infow("link disabled", "alias", alias)
+			if err = renderDisabledPage(w); err != nil {
+				log.Error("failed to render disabled page", zap.Error(err))
+			}
+			return
+		}
+
+		now := time.Now()
+		if link.IsExpired(now) {
+			log.Infow("link expired", "alias", alias, "expires_at", link.ExpiresAt)
+			render.Status(r, http.StatusGone)
+			render.JSON(w, r, resp.Error("link expired"))
+			return
+		}
+
+		if link.IsPending(now) {
+			log.Infow("link not active yet", "alias", alias, "active_from", link.ActiveFrom)
+			if cfg.PendingPage {
+				if err = renderPendingPage(w, *link.ActiveFrom); err != nil {
+					log.Error("failed to render pending page", zap.Error(err))
+				}
+				return
+			}
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.Error("url not found"))
+			return
+		}
+		if link.IsInactive(now) {
+			log.Infow("link no longer active", "alias", alias, "active_until", link.ActiveUntil)
+			render.Status(r, http.StatusGone)
+			render.JSON(w, r, resp.Error("link is no longer active"))
+			return
+		}
+
+		// Preview bots get the card of the link instead of following it, so
+		// they do not count as clicks. The user agent is easy to fake, so
+		// the card never holds the destination, and links gated by a
+		// password, a click limit or the preview page get no card.
+		if r.Method == http.MethodGet && servesCard(link) && unfurl.IsCrawler(r.UserAgent()) {
+			log.Infow("serving link card", "alias", alias, "user_agent", r.UserAgent())
+			if err = renderUnfurlPage(w, link); err != nil {
+				log.Error("failed to render link card", zap.Error(err))
+			}
+			return
+		}
+
+		if r.Method == http.MethodGet && wantsPreview(r, link) {
+			if err = renderPreviewPage(w, r, link); err != nil {
+				log.Error("failed to render preview page", zap.Error(err))
+			}
+			return
+		}
+
+		// A POST to a protected link submits the password form.
+		passwordForm := link.Protected && r.Method == http.MethodPost
+		if link.Protected && !hasAccess(r, link, cfg.CookieSecret, now) {
+			if r.Method != http.MethodPost {
+				if err = renderPasswordPage(w, r, http.StatusOK, alias, ""); err != nil {
+					log.Error("failed to render password page", zap.Error(err))
+				}
+				return
+			}
+			password := r.PostFormValue("password")
+			if bcrypt.CompareHashAndPassword(link.PasswordHash, []byte(password)) != nil {
+				log.Infow("wrong link password", "alias", alias)
+				if err = renderPasswordPage(w, r, http.StatusUnauthorized, alias, "Wrong password"); err != nil {
+					log.Error("failed to render password page", zap.Error(err))
+				}
+				return
+			}
+			setAccessCookie(w, r, link, cfg.CookieSecret, cfg.CookieTTL, now)
+		}
+
+		if link.IsLimited() {
+			// The click is counted by the storage; a cached copy only tells
+			// that the link is limited.
+			w.Header().Set("Cache-Control", "no-store")
+			link, err = urlGetter.ConsumeClick(alias)
+			if err != nil {
+				switch {
+				case errors.Is(err, storage.ErrLinkExhausted):
+					log.Infow("link click limit reached", "alias", alias)
+					if err = cacheGetter.Delete(r.Context(), alias); err != nil {
+						log.Error("failed to delete alias from cache", zap.Error(err))
+					}
+					render.Status(r, http.StatusGone)
+					render.JSON(w, r, resp.Error("link click limit reached"))
+				case errors.Is(err, storage.ErrURLNotFound):
+					log.Infow("url not found", "alias", alias)
+					render.Status(r, http.StatusNotFound)
+					render.JSON(w, r, resp.Error("url not found"))
+				default:
+					log.Error("failed to consume click", zap.Error(err))
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, resp.Error("failed to get url"))
+				}
+				return
+			}
+			if link.ClicksLeft != nil && *link.ClicksLeft == 0 {
+				if err = cacheGetter.Delete(r.Context(), alias); err != nil {
+					log.Error("failed to delete alias from cache", zap.Error(err))
+				}
+			}
+		}
+
+		// Responses of targeted and split links depend on the visitor, so
+		// shared caches must not store them.
+		if (len(link.Rules) > 0 || len(link.Variants) > 0) && w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", "private")
+		}
+		target := link.URL
+		matched := false
+		if len(link.Rules) > 0 {
+			w.Header().Set("Vary", "User-Agent, Accept-Language")
+			var ruleURL string
+			if ruleURL, matched = targeting.Match(link.Rules, targeting.FromRequest(r, cfg.Countries)); matched {
+				log.Infow("routing rule matched", "alias", alias, "url", ruleURL)
+				target = ruleURL
+			}
+		}
+		var variant string
+		if !matched && len(link.Variants) > 0 {
+			chosen := chooseVariant(r, link)
+			if link.StickyVariants {
+				setVariantCookie(w, r, alias, chosen.Name, cfg.VariantCookieTTL)
+			}
+			log.Infow("variant chosen", "alias", alias, "variant", chosen.Name)
+			target, variant = chosen.URL, chosen.Name
+			m.IncVariantServed(variant)
+		}
+
+		var forwarded url.Values
+		if link.ForwardQuery {
+			forwarded = r.URL.Query()
+			forwarded.Del(previewParam)
+			forwarded.Del(confirmParam)
+		}
+		if target, err = destination.Build(target, forwarded, link.UTM); err != nil {
+			log.Error("failed to build destination", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to get url"))
+			return
+		}
+
 		m.IncLinksRedirected()
-		http.Redirect(w, r, url, http.StatusFound)
+		tracker.Track(storage.Click{
+			Alias:     alias,
+			ClickedAt: now,
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+			IP:        clientip.FromRequest(r),
+			RequestID: middleware.GetReqID(r.Context()),
+			Variant:   variant,
+		})
+		status := redirectStatus(link, cfg.DefaultStatus)
+		if passwordForm {
+			// The password form must not be sent on to the destination.
+			status = http.StatusSeeOther
+		}
+		if (status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect) && cfg.PermanentCacheControl != "" {
+			switch cacheControl := w.Header().Get("Cache-Control"); cacheControl {
+			case "":
+				w.Header().Set("Cache-Control", cfg.PermanentCacheControl)
+			case "no-store":
+			default:
+				w.Header().Set("Cache-Control", cacheControl+", "+cfg.PermanentCacheControl)
+			}
+		}
+		http.Redirect(w, r, target, status)
+	}
+}
+
+// servesCard reports whether preview bots get the card of the link.
+func servesCard(link *storage.Link) bool {
+	return !link.Meta.IsZero() && !link.Protected && !link.IsLimited() && !link.Interstitial
+}
+
+// redirectStatus returns the redirect type of the link, falling back to
+// defaultStatus and then to 302 Found.
+func redirectStatus(link *storage.Link, defaultStatus int) int {
+	if storage.IsRedirectType(link.RedirectType) {
+		return link.RedirectType
+	}
+	if storage.IsRedirectType(defaultStatus) {
+		return defaultStatus
+	}
+	return http.StatusFound
+}
+
+// lookup returns the link from the cache or the storage. The cache does not
+// keep password hashes, so protected links are always read from the storage.
+func lookup(ctx context.Context, log *zap.SugaredLogger, alias string, urlGetter URLGetter, cacheGetter CacheGetter) (*storage.Link, error) {
+	link, err := cacheGetter.Get(ctx, alias)
+	if err == nil && !link.Protected {
+		log.Infow("got url from cache", "url", link.URL)
+		return link, nil
+	}
+	link, err = urlGetter.Get(alias)
+	if err != nil {
+		return nil, err
 	}
+	log.Infow("got url", "url", link.URL)
+	return link, nil
 }
@@ -14,15 +14,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestRedirectHandler(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
 	cases := []struct {
 		name       string
 		alias      string
 		mockError  error
 		cacheError error
 		cacheURL   string
+		expiresAt  *time.Time
 		statusCode int
 	}{
 		{
@@ -31,6 +35,21 @@ func TestRedirectHandler(t *testing.T) {
 			cacheURL:   "http://example.com",
 			statusCode: http.StatusFound,
 		},
+		{
+			name:       "Expired link",
+			alias:      "alias",
+			cacheError: storage.ErrAliasNotFound,
+			cacheURL:   "http://example.com",
+			expiresAt:  &past,
+			statusCode: http.StatusGone,
+		},
+		{
+			name:       "Link not expired yet",
+			alias:      "alias",
+			cacheURL:   "http://example.com",
+			expiresAt:  &future,
+			statusCode: http.StatusFound,
+		},
 		{
 			name:       "Empty alias",
 			alias:      "",
@@ -71,22 +90,39 @@ func TestRedirectHandler(t *testing.T) {
 			cacheGetterMock := mocker.NewCacheGetter(t)
 			metricsGetterMock := mocker.NewMetricsGetter(t)
 			metricsGetterMock.On("IncLinksRedirected").Maybe()
+			clickTrackerMock := mocker.NewClickTracker(t)
+			if tc.statusCode == http.StatusFound {
+				clickTrackerMock.On("Track", mock.MatchedBy(func(c storage.Click) bool {
+					return c.Alias == tc.alias && c.Referrer == "https://t.me" && c.IP == "203.0.113.7"
+				})).Once()
+			}
+			var link *storage.Link
+			if tc.cacheURL != "" {
+				link = &storage.Link{Alias: tc.alias, URL: tc.cacheURL, ExpiresAt: tc.expiresAt}
+			}
 			if tc.alias != "" {
+				var cached *storage.Link
+				if tc.cacheError == nil {
+					cached = link
+				}
 				cacheGetterMock.On("Get", mock.Anything, tc.alias).
-					Return(tc.cacheURL, tc.cacheError).
+					Return(cached, tc.cacheError).
 					Once()
 
 				if tc.cacheError != nil || tc.cacheURL == "" {
 					urlGetterMock.On("Get", tc.alias).
-						Return(tc.cacheURL, tc.mockError).
+						Return(link, tc.mockError).
 						Once()
 				}
 			}
 
-			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock)
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, redirect.Config{})
 			url := fmt.Sprintf("/%s", tc.alias)
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
+			req.Header.Set("Referer", "https://t.me")
+			// nginx appends the peer address to the chain sent by the client.
+			req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
 
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("alias", tc.alias)
@@ -99,6 +135,7 @@ func TestRedirectHandler(t *testing.T) {
 
 			urlGetterMock.AssertExpectations(t)
 			cacheGetterMock.AssertExpectations(t)
+			clickTrackerMock.AssertExpectations(t)
 		})
 	}
 }
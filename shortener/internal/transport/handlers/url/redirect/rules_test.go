@@ -0,0 +1,97 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type countries map[string]string
+
+func (c countries) Country(ip string) string {
+	return c[ip]
+}
+
+func TestRedirectTargetedLink(t *testing.T) {
+	const alias = "app"
+	link := &storage.Link{
+		Alias: alias,
+		URL:   "https://example.com/app",
+		Rules: []storage.Rule{
+			{OS: "ios", URL: "https://apps.apple.com/app"},
+			{OS: "android", URL: "https://play.google.com/store/apps"},
+			{Country: "FR", URL: "https://example.fr/app"},
+		},
+	}
+	cases := []struct {
+		name      string
+		userAgent string
+		ip        string
+		location  string
+	}{
+		{
+			name:      "iOS",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148",
+			location:  "https://apps.apple.com/app",
+		},
+		{
+			name:      "Android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) Mobile Safari/537.36",
+			location:  "https://play.google.com/store/apps",
+		},
+		{
+			name:      "Country",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			ip:        "203.0.113.5",
+			location:  "https://example.fr/app",
+		},
+		{
+			name:      "Default",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			location:  "https://example.com/app",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(link, nil).Once()
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			metricsGetterMock.On("IncLinksRedirected").Once()
+			clickTrackerMock := mocker.NewClickTracker(t)
+			clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Once()
+
+			cfg := redirect.Config{Countries: countries{"203.0.113.5": "FR"}}
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, cfg)
+			req, err := http.NewRequest(http.MethodGet, "/"+alias, nil)
+			require.NoError(t, err)
+			req.Header.Set("User-Agent", tc.userAgent)
+			req.RemoteAddr = "198.51.100.1:5555"
+			if tc.ip != "" {
+				req.RemoteAddr = tc.ip + ":5555"
+			}
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tc.location, rr.Header().Get("Location"))
+			require.Equal(t, "private", rr.Header().Get("Cache-Control"))
+		})
+	}
+}
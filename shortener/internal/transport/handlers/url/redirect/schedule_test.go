@@ -0,0 +1,96 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRedirectScheduledLink(t *testing.T) {
+	const (
+		alias  = "launch"
+		target = "https://example.com/launch"
+	)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	cases := []struct {
+		name        string
+		activeFrom  *time.Time
+		activeUntil *time.Time
+		pendingPage bool
+		statusCode  int
+		contentType string
+	}{
+		{
+			name:        "Within window",
+			activeFrom:  &past,
+			activeUntil: &future,
+			statusCode:  http.StatusFound,
+		},
+		{
+			name:        "Pending",
+			activeFrom:  &future,
+			statusCode:  http.StatusNotFound,
+			contentType: "application/json",
+		},
+		{
+			name:        "Pending page",
+			activeFrom:  &future,
+			pendingPage: true,
+			statusCode:  http.StatusNotFound,
+			contentType: "text/html; charset=utf-8",
+		},
+		{
+			name:        "Window closed",
+			activeUntil: &past,
+			statusCode:  http.StatusGone,
+			contentType: "application/json",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := &storage.Link{Alias: alias, URL: target, ActiveFrom: tc.activeFrom, ActiveUntil: tc.activeUntil}
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(link, nil).Once()
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			clickTrackerMock := mocker.NewClickTracker(t)
+			if tc.statusCode == http.StatusFound {
+				metricsGetterMock.On("IncLinksRedirected").Once()
+				clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Once()
+			}
+
+			cfg := redirect.Config{PendingPage: tc.pendingPage}
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, cfg)
+			req, err := http.NewRequest(http.MethodGet, "/"+alias, nil)
+			require.NoError(t, err)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			if tc.contentType != "" {
+				require.Contains(t, rr.Header().Get("Content-Type"), tc.contentType)
+			}
+			if tc.pendingPage {
+				require.Contains(t, rr.Body.String(), "not active yet")
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectStatus(t *testing.T) {
+	const alias = "moved"
+	cases := []struct {
+		name          string
+		method        string
+		redirectType  int
+		defaultStatus int
+		maxClicks     int
+		statusCode    int
+		cacheControl  string
+	}{
+		{
+			name:       "Fallback",
+			statusCode: http.StatusFound,
+		},
+		{
+			name:          "Service default",
+			defaultStatus: http.StatusTemporaryRedirect,
+			statusCode:    http.StatusTemporaryRedirect,
+		},
+		{
+			name:          "Link overrides default",
+			redirectType:  http.StatusMovedPermanently,
+			defaultStatus: http.StatusTemporaryRedirect,
+			statusCode:    http.StatusMovedPermanently,
+			cacheControl:  "no-cache",
+		},
+		{
+			name:          "Permanent default",
+			defaultStatus: http.StatusPermanentRedirect,
+			statusCode:    http.StatusPermanentRedirect,
+			cacheControl:  "no-cache",
+		},
+		{
+			name:         "POST keeps its method",
+			method:       http.MethodPost,
+			redirectType: http.StatusTemporaryRedirect,
+			statusCode:   http.StatusTemporaryRedirect,
+		},
+		{
+			name:          "POST to a permanent redirect",
+			method:        http.MethodPost,
+			defaultStatus: http.StatusPermanentRedirect,
+			statusCode:    http.StatusPermanentRedirect,
+			cacheControl:  "no-cache",
+		},
+		{
+			name:         "Limited link stays uncached",
+			redirectType: http.StatusMovedPermanently,
+			maxClicks:    5,
+			statusCode:   http.StatusMovedPermanently,
+			cacheControl: "no-store",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := &storage.Link{Alias: alias, URL: "https://example.com/new", RedirectType: tc.redirectType, MaxClicks: tc.maxClicks}
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(link, nil).Once()
+			if tc.maxClicks > 0 {
+				clicksLeft := tc.maxClicks - 1
+				consumed := *link
+				consumed.ClicksLeft = &clicksLeft
+				urlGetterMock.On("ConsumeClick", alias).Return(&consumed, nil).Once()
+			}
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			metricsGetterMock.On("IncLinksRedirected").Once()
+			clickTrackerMock := mocker.NewClickTracker(t)
+			clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Once()
+
+			cfg := redirect.Config{DefaultStatus: tc.defaultStatus, PermanentCacheControl: "no-cache"}
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, cfg)
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, "/"+alias, nil)
+			require.NoError(t, err)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			require.Equal(t, "https://example.com/new", rr.Header().Get("Location"))
+			require.Equal(t, tc.cacheControl, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package redirect_test
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectCrawler(t *testing.T) {
+	const (
+		alias   = "card"
+		target  = "https://example.com/sale"
+		slackUA = "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"
+	)
+	card := &storage.Meta{Title: `Spring "sale"`, Description: "Everything -20%", Image: "https://example.com/cover.png"}
+	cases := []struct {
+		name       string
+		link       *storage.Link
+		userAgent  string
+		statusCode int
+		contains   []string
+	}{
+		{
+			name:       "Card",
+			link:       &storage.Link{Alias: alias, URL: target, Meta: card},
+			userAgent:  slackUA,
+			statusCode: http.StatusOK,
+			contains: []string{
+				`<meta property="og:title" content="Spring &#34;sale&#34;">`,
+				`<meta property="og:description" content="Everything -20%">`,
+				`<meta property="og:image" content="https://example.com/cover.png">`,
+				`<meta name="twitter:card" content="summary_large_image">`,
+			},
+		},
+		{
+			name:       "Limited link",
+			link:       &storage.Link{Alias: alias, URL: target, Meta: card, MaxClicks: 1},
+			userAgent:  "facebookexternalhit/1.1",
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "Interstitial link",
+			link:       &storage.Link{Alias: alias, URL: target, Meta: card, Interstitial: true},
+			userAgent:  slackUA,
+			statusCode: http.StatusOK,
+			contains:   []string{`href="/card?confirm=1"`},
+		},
+		{
+			name:       "Browser",
+			link:       &storage.Link{Alias: alias, URL: target, Meta: card},
+			userAgent:  "Mozilla/5.0 (X11; Linux x86_64) Firefox/126.0",
+			statusCode: http.StatusFound,
+		},
+		{
+			name:       "No card",
+			link:       &storage.Link{Alias: alias, URL: target},
+			userAgent:  slackUA,
+			statusCode: http.StatusFound,
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(tc.link, nil).Once()
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			clickTrackerMock := mocker.NewClickTracker(t)
+			if tc.link.IsLimited() {
+				urlGetterMock.On("ConsumeClick", alias).Return(tc.link, nil).Once()
+			}
+			if tc.statusCode == http.StatusFound {
+				metricsGetterMock.On("IncLinksRedirected").Once()
+				clickTrackerMock.On("Track", mock.AnythingOfType("storage.Click")).Once()
+			}
+
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, redirect.Config{})
+			req := previewRequest(t, alias, "/"+alias)
+			req.Header.Set("User-Agent", tc.userAgent)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			if tc.statusCode == http.StatusFound {
+				require.Equal(t, target, rr.Header().Get("Location"))
+				return
+			}
+			require.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+			for _, s := range tc.contains {
+				require.Contains(t, rr.Body.String(), s)
+			}
+			if !tc.link.Interstitial {
+				require.NotContains(t, rr.Body.String(), target, "the card does not reveal the destination")
+			}
+		})
+	}
+}
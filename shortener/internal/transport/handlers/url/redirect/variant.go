@@ -0,0 +1,53 @@
+package redirect
+
+import (
+	"linkify/internal/storage"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// variantCookiePrefix is followed by the alias in the name of the cookie
+// that keeps a visitor on the same variant of a sticky split link.
+const variantCookiePrefix = "link_variant_"
+
+// chooseVariant returns the variant remembered in the request cookie when
+// the link is sticky and the variant still exists, or a random one by weight.
+func chooseVariant(r *http.Request, link *storage.Link) storage.Variant {
+	if link.StickyVariants {
+		if cookie, err := r.Cookie(variantCookiePrefix + link.Alias); err == nil {
+			for _, variant := range link.Variants {
+				if variant.Name == cookie.Value {
+					return variant
+				}
+			}
+		}
+	}
+	total := 0
+	for _, variant := range link.Variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return link.Variants[0]
+	}
+	n := rand.IntN(total)
+	for _, variant := range link.Variants {
+		if n < variant.Weight {
+			return variant
+		}
+		n -= variant.Weight
+	}
+	return link.Variants[len(link.Variants)-1]
+}
+
+func setVariantCookie(w http.ResponseWriter, r *http.Request, alias, variant string, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookiePrefix + alias,
+		Value:    variant,
+		Path:     "/" + alias,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,128 @@
+package redirect_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/redirect"
+	mocker "linkify/internal/transport/handlers/url/redirect/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRedirectSplitLink(t *testing.T) {
+	const alias = "sale"
+	variants := []storage.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 1},
+		{Name: "b", URL: "https://example.com/b", Weight: 0},
+	}
+	cases := []struct {
+		name      string
+		sticky    bool
+		cookie    string
+		userAgent string
+		location  string
+		variant   string
+		setCookie bool
+	}{
+		{
+			name:     "Weighted",
+			location: "https://example.com/a",
+			variant:  "a",
+		},
+		{
+			name:      "Sticky first visit",
+			sticky:    true,
+			location:  "https://example.com/a",
+			variant:   "a",
+			setCookie: true,
+		},
+		{
+			name:      "Sticky returning visitor",
+			sticky:    true,
+			cookie:    "b",
+			location:  "https://example.com/b",
+			variant:   "b",
+			setCookie: true,
+		},
+		{
+			name:      "Removed variant in cookie",
+			sticky:    true,
+			cookie:    "c",
+			location:  "https://example.com/a",
+			variant:   "a",
+			setCookie: true,
+		},
+		{
+			name:     "Cookie of non-sticky link",
+			cookie:   "b",
+			location: "https://example.com/a",
+			variant:  "a",
+		},
+		{
+			name:      "Routing rule first",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			location:  "https://apps.apple.com/app",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := &storage.Link{
+				Alias:          alias,
+				URL:            "https://example.com",
+				Rules:          []storage.Rule{{OS: "ios", URL: "https://apps.apple.com/app"}},
+				Variants:       variants,
+				StickyVariants: tc.sticky,
+			}
+			urlGetterMock := mocker.NewURLGetter(t)
+			cacheGetterMock := mocker.NewCacheGetter(t)
+			cacheGetterMock.On("Get", mock.Anything, alias).Return(link, nil).Once()
+			metricsGetterMock := mocker.NewMetricsGetter(t)
+			metricsGetterMock.On("IncLinksRedirected").Once()
+			if tc.variant != "" {
+				metricsGetterMock.On("IncVariantServed", tc.variant).Once()
+			}
+			clickTrackerMock := mocker.NewClickTracker(t)
+			clickTrackerMock.On("Track", mock.MatchedBy(func(click storage.Click) bool {
+				return click.Variant == tc.variant
+			})).Once()
+
+			cfg := redirect.Config{VariantCookieTTL: time.Hour}
+			handler := redirect.New(zapdiscard.New(), urlGetterMock, cacheGetterMock, metricsGetterMock, clickTrackerMock, cfg)
+			req, err := http.NewRequest(http.MethodGet, "/"+alias, nil)
+			require.NoError(t, err)
+			req.Header.Set("User-Agent", tc.userAgent)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "link_variant_" + alias, Value: tc.cookie})
+			}
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusFound, rr.Code)
+			require.Equal(t, tc.location, rr.Header().Get("Location"))
+			require.Equal(t, "private", rr.Header().Get("Cache-Control"))
+			cookies := rr.Result().Cookies()
+			if !tc.setCookie {
+				require.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			require.Equal(t, "link_variant_"+alias, cookies[0].Name)
+			require.Equal(t, tc.variant, cookies[0].Value)
+			require.Equal(t, 3600, cookies[0].MaxAge)
+		})
+	}
+}
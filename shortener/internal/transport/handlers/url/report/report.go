@@ -0,0 +1,129 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/lib/clientip"
+	"linkify/internal/storage"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Request describes why a link is reported.
+type Request struct {
+	Reason  string `json:"reason" validate:"required,oneof=phishing malware spam illegal other"`
+	Comment string `json:"comment,omitempty" validate:"max=1000"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=Reporter
+type Reporter interface {
+	SaveReport(report storage.Report) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=RateLimiter
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error)
+}
+
+// Limit is how many reports a single address may send per Window.
+type Limit struct {
+	Reports int
+	Window  time.Duration
+}
+
+// New handles reporting an abusive link.
+// @Summary      Report URL
+// @Description  Reports a link as abusive for review by the admins. Every address may send a limited number of reports per window
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Param        alias    path      string   true  "URL alias"
+// @Param        request  body      Request  true  "Report"
+// @Success      202      {object}  response.Response  "Report accepted"
+// @Failure      400      {object}  response.Response  "Invalid request"
+// @Failure      404      {object}  response.Response  "Alias not found"
+// @Failure      429      {object}  response.Response  "Too many reports"
+// @Failure      500      {object}  response.Response  "Internal server error"
+// @Router       /{alias}/report [post]
+func New(log *zap.SugaredLogger, reporter Reporter, limiter RateLimiter, limit Limit) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+
+		ip := clientip.FromRequest(r)
+		allowed, err := limiter.Allow(r.Context(), "report:"+ip, limit.Reports, limit.Window)
+		if err != nil {
+			// Reports keep working while the rate limiter is unavailable.
+			log.Error("failed to check report rate limit", zap.Error(err))
+			allowed = true
+		}
+		if !allowed {
+			log.Infow("too many reports", "ip", ip)
+			w.Header().Set("Retry-After", retryAfter(limit.Window))
+			render.Status(r, http.StatusTooManyRequests)
+			render.JSON(w, r, resp.Error("too many reports"))
+			return
+		}
+
+		var req Request
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		if err = validator.New().Struct(req); err != nil {
+			var validateErrs validator.ValidationErrors
+			errors.As(err, &validateErrs)
+
+			log.Error("failed to validate request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidateError(validateErrs))
+			return
+		}
+
+		err = reporter.SaveReport(storage.Report{
+			Alias:      alias,
+			Reason:     req.Reason,
+			Comment:    req.Comment,
+			ReporterIP: ip,
+			CreatedAt:  time.Now(),
+		})
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+				return
+			}
+			log.Error("failed to save report", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to save report"))
+			return
+		}
+
+		log.Infow("link reported", "alias", alias, "reason", req.Reason)
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// retryAfter returns the Retry-After value for a rate limit window.
+func retryAfter(window time.Duration) string {
+	return strconv.Itoa(int(window.Round(time.Second) / time.Second))
+}
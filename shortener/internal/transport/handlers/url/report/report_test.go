@@ -0,0 +1,149 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/report"
+	mocker "linkify/internal/transport/handlers/url/report/mocks"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportHandler(t *testing.T) {
+	const (
+		alias = "alias"
+		ip    = "203.0.113.5"
+	)
+	limit := report.Limit{Reports: 5, Window: time.Hour}
+	cases := []struct {
+		name       string
+		body       string
+		allowed    bool
+		limitError error
+		save       bool
+		mockError  error
+		statusCode int
+		respError  string
+	}{
+		{
+			name:       "Success",
+			body:       `{"reason":"phishing","comment":"asks for my bank password"}`,
+			allowed:    true,
+			save:       true,
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "Rate limiter unavailable",
+			body:       `{"reason":"spam"}`,
+			limitError: errors.New("connection refused"),
+			save:       true,
+			statusCode: http.StatusAccepted,
+		},
+		{
+			name:       "Too many reports",
+			body:       `{"reason":"spam"}`,
+			statusCode: http.StatusTooManyRequests,
+			respError:  "too many reports",
+		},
+		{
+			name:       "Missing reason",
+			body:       `{"comment":"bad"}`,
+			allowed:    true,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Reason is required",
+		},
+		{
+			name:       "Unknown reason",
+			body:       `{"reason":"boring"}`,
+			allowed:    true,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Reason is not valid",
+		},
+		{
+			name:       "Comment too long",
+			body:       `{"reason":"other","comment":"` + strings.Repeat("a", 1001) + `"}`,
+			allowed:    true,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Comment is not valid",
+		},
+		{
+			name:       "Invalid JSON",
+			body:       `{"reason":`,
+			allowed:    true,
+			statusCode: http.StatusBadRequest,
+			respError:  "failed to decode request",
+		},
+		{
+			name:       "Not found",
+			body:       `{"reason":"malware"}`,
+			allowed:    true,
+			save:       true,
+			mockError:  storage.ErrURLNotFound,
+			statusCode: http.StatusNotFound,
+			respError:  "alias not found",
+		},
+		{
+			name:       "Storage error",
+			body:       `{"reason":"malware"}`,
+			allowed:    true,
+			save:       true,
+			mockError:  errors.New("unexpected error"),
+			statusCode: http.StatusInternalServerError,
+			respError:  "failed to save report",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporterMock := mocker.NewReporter(t)
+			rateLimiterMock := mocker.NewRateLimiter(t)
+			rateLimiterMock.On("Allow", mock.Anything, "report:"+ip, limit.Reports, limit.Window).
+				Return(tc.allowed, tc.limitError).
+				Once()
+			if tc.save {
+				isExpected := func(r storage.Report) bool {
+					return r.Alias == alias && r.ReporterIP == ip && r.Reason != "" && !r.CreatedAt.IsZero()
+				}
+				reporterMock.On("SaveReport", mock.MatchedBy(isExpected)).
+					Return(tc.mockError).
+					Once()
+			}
+
+			handler := report.New(zapdiscard.New(), reporterMock, rateLimiterMock, limit)
+			req, err := http.NewRequest(http.MethodPost, "/"+alias+"/report", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			req.RemoteAddr = "10.0.0.1:4321"
+			// Only the entry appended by nginx counts, not the chain sent by
+			// the client.
+			req.Header.Set("X-Forwarded-For", "198.51.100.9, "+ip)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			var body resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			require.Equal(t, tc.respError, body.Error)
+			if tc.statusCode == http.StatusTooManyRequests {
+				require.Equal(t, "3600", rr.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
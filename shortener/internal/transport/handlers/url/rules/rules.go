@@ -0,0 +1,195 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/etag"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+)
+
+// MaxRules limits the number of routing rules of a single link.
+const MaxRules = 20
+
+// Rule sends visitors matching all of its conditions to URL. At least one
+// condition must be set; Country needs a GeoIP database on the server. URL
+// is screened like the destination of the link.
+type Rule struct {
+	OS       string `json:"os,omitempty" validate:"omitempty,oneof=ios android windows macos linux"`
+	Device   string `json:"device,omitempty" validate:"omitempty,oneof=mobile tablet desktop"`
+	Language string `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+	Country  string `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	URL      string `json:"url" validate:"required,url,allowed_scheme,public_host,allowed_domain,not_self,safe_url"`
+}
+
+// Request replaces all routing rules of a link. Rules are checked in order
+// and the first matching one wins; an empty list removes them.
+type Request struct {
+	Rules []Rule `json:"rules" validate:"dive"`
+}
+
+// Response represents the link with its new rules.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Link
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLUpdater
+type URLUpdater interface {
+	Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Screener adds the checks of link destinations to a validator. See
+// screening.Screener.
+type Screener interface {
+	Register(v *validator.Validate)
+}
+
+// New handles replacing the routing rules of a link by its alias.
+// @Summary      Set routing rules
+// @Description  Replaces the rules that send visitors to other URLs depending on their OS, device, language or country. Rule URLs are screened like link destinations. Send the ETag of the link in If-Match to change only an unchanged version
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias     path      string   true   "URL alias"
+// @Param        If-Match  header    string   false  "ETag of the version being changed"
+// @Param        request   body      Request  true   "Routing rules"
+// @Success      200       {object}  Response
+// @Failure      400       {object}  response.Response  "Invalid request"
+// @Failure      401       {object}  response.Response  "Unauthorized"
+// @Failure      403       {object}  response.Response  "Alias belongs to another user"
+// @Failure      404       {object}  response.Response  "Alias not found"
+// @Failure      412       {object}  response.Response  "Link was modified concurrently"
+// @Failure      500       {object}  response.Response  "Internal server error"
+// @Router       /api/url/{alias}/rules [put]
+func New(log *zap.SugaredLogger, urlUpdater URLUpdater, cacheDeleter CacheDeleter, screener Screener) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		version, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Infow("invalid If-Match header", "header", r.Header.Get("If-Match"))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid If-Match header"))
+			return
+		}
+
+		var req Request
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		if len(req.Rules) > MaxRules {
+			log.Infow("too many rules", "count", len(req.Rules))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(fmt.Sprintf("at most %d rules are allowed", MaxRules)))
+			return
+		}
+		validate := validator.New()
+		screener.Register(validate)
+		if err = validate.StructCtx(r.Context(), req); err != nil {
+			var validateErrs validator.ValidationErrors
+			errors.As(err, &validateErrs)
+
+			log.Error("failed to validate request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidateError(validateErrs))
+			return
+		}
+		rules, err := toRules(req.Rules)
+		if err != nil {
+			log.Infow("invalid rules", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		link, err := urlUpdater.Update(alias, ownerID, auth.IsAdminFromContext(r.Context()), version, storage.LinkUpdate{
+			SetRules: true,
+			Rules:    rules,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrURLNotFound):
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+			case errors.Is(err, storage.ErrForbidden):
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+			case errors.Is(err, storage.ErrVersionConflict):
+				log.Infow("version conflict", "alias", alias, "version", version)
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, resp.Error("link was modified concurrently"))
+			default:
+				log.Error("failed to set rules", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to set rules"))
+			}
+			return
+		}
+		if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+			log.Error("failed to delete alias from cache", zap.Error(err))
+		}
+
+		log.Infow("rules updated", "alias", alias, "rules", len(rules))
+		w.Header().Set("ETag", etag.Format(link.Version))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     *link,
+		})
+	}
+}
+
+// toRules converts validated request rules, rejecting the ones without
+// conditions since they would shadow every rule after them.
+func toRules(reqRules []Rule) ([]storage.Rule, error) {
+	rules := make([]storage.Rule, 0, len(reqRules))
+	for i, rule := range reqRules {
+		if rule.OS == "" && rule.Device == "" && rule.Language == "" && rule.Country == "" {
+			return nil, fmt.Errorf("rule %d has no conditions", i+1)
+		}
+		rules = append(rules, storage.Rule{
+			OS:       rule.OS,
+			Device:   rule.Device,
+			Language: rule.Language,
+			Country:  rule.Country,
+			URL:      rule.URL,
+		})
+	}
+	return rules, nil
+}
@@ -0,0 +1,210 @@
+package rules_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/config"
+	"linkify/internal/lib/api/etag"
+	"linkify/internal/screening"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/rules"
+	mocker "linkify/internal/transport/handlers/url/rules/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRulesHandler(t *testing.T) {
+	const (
+		alias   = "alias"
+		ownerID = "42"
+	)
+	appRules := []storage.Rule{
+		{OS: "ios", URL: "https://apps.apple.com/app"},
+		{OS: "android", Device: "mobile", URL: "https://play.google.com/store/apps"},
+		{Language: "de-AT", Country: "AT", URL: "https://example.at"},
+	}
+	appBody := `{"rules":[
+		{"os":"ios","url":"https://apps.apple.com/app"},
+		{"os":"android","device":"mobile","url":"https://play.google.com/store/apps"},
+		{"language":"de-AT","country":"AT","url":"https://example.at"}
+	]}`
+	cases := []struct {
+		name         string
+		body         string
+		ifMatch      string
+		version      int
+		update       storage.LinkUpdate
+		expectUpdate bool
+		mockError    error
+		statusCode   int
+		respError    string
+	}{
+		{
+			name:         "Success",
+			body:         appBody,
+			update:       storage.LinkUpdate{SetRules: true, Rules: appRules},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Remove rules",
+			body:         `{"rules":[]}`,
+			ifMatch:      etag.Format(4),
+			version:      4,
+			update:       storage.LinkUpdate{SetRules: true, Rules: []storage.Rule{}},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:       "Unknown OS",
+			body:       `{"rules":[{"os":"symbian","url":"https://example.com"}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field OS is not valid",
+		},
+		{
+			name:       "Invalid country",
+			body:       `{"rules":[{"country":"Germany","url":"https://example.com"}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Country is not valid",
+		},
+		{
+			name:       "Invalid url",
+			body:       `{"rules":[{"os":"ios","url":"app store"}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field URL is not a valid URL",
+		},
+		{
+			name:       "Script url",
+			body:       `{"rules":[{"os":"ios","url":"javascript:alert(1)"}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field URL has a scheme that is not allowed",
+		},
+		{
+			name:       "Private url",
+			body:       `{"rules":[{"os":"ios","url":"http://10.0.0.1/admin"}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field URL points to a private or local address",
+		},
+		{
+			name:       "Rule without conditions",
+			body:       `{"rules":[{"os":"ios","url":"https://apps.apple.com/app"},{"url":"https://example.com"}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "rule 2 has no conditions",
+		},
+		{
+			name:       "Too many rules",
+			body:       `{"rules":[` + strings.Repeat(`{"os":"ios","url":"https://example.com"},`, rules.MaxRules) + `{"os":"ios","url":"https://example.com"}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "at most 20 rules are allowed",
+		},
+		{
+			name:       "Invalid If-Match",
+			body:       appBody,
+			ifMatch:    "abc",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid If-Match header",
+		},
+		{
+			name:         "Version conflict",
+			body:         appBody,
+			ifMatch:      etag.Format(2),
+			version:      2,
+			update:       storage.LinkUpdate{SetRules: true, Rules: appRules},
+			expectUpdate: true,
+			mockError:    storage.ErrVersionConflict,
+			statusCode:   http.StatusPreconditionFailed,
+			respError:    "link was modified concurrently",
+		},
+		{
+			name:         "Not owner",
+			body:         appBody,
+			update:       storage.LinkUpdate{SetRules: true, Rules: appRules},
+			expectUpdate: true,
+			mockError:    storage.ErrForbidden,
+			statusCode:   http.StatusForbidden,
+			respError:    "access denied",
+		},
+		{
+			name:         "Not found",
+			body:         appBody,
+			update:       storage.LinkUpdate{SetRules: true, Rules: appRules},
+			expectUpdate: true,
+			mockError:    storage.ErrURLNotFound,
+			statusCode:   http.StatusNotFound,
+			respError:    "alias not found",
+		},
+		{
+			name:         "Storage error",
+			body:         appBody,
+			update:       storage.LinkUpdate{SetRules: true, Rules: appRules},
+			expectUpdate: true,
+			mockError:    errors.New("unexpected error"),
+			statusCode:   http.StatusInternalServerError,
+			respError:    "failed to set rules",
+		},
+	}
+	screener, err := screening.New(config.Screening{BlockPrivate: true}, zapdiscard.New())
+	require.NoError(t, err)
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocker.NewURLUpdater(t)
+			cacheDeleterMock := mocker.NewCacheDeleter(t)
+			if tc.expectUpdate {
+				var result *storage.Link
+				if tc.mockError == nil {
+					result = &storage.Link{
+						Alias:   alias,
+						URL:     "https://example.com",
+						OwnerID: ownerID,
+						Version: tc.version + 1,
+						Rules:   tc.update.Rules,
+					}
+					cacheDeleterMock.On("Delete", mock.Anything, alias).
+						Return(nil).
+						Once()
+				}
+				urlUpdaterMock.On("Update", alias, ownerID, false, tc.version, tc.update).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := rules.New(zapdiscard.New(), urlUpdaterMock, cacheDeleterMock, screener)
+			req, err := http.NewRequest(http.MethodPut, "/url/"+alias+"/rules", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, ownerID, "user@example.com", false))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp rules.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, etag.Format(tc.version+1), rr.Header().Get("ETag"))
+				require.Len(t, resp.Rules, len(tc.update.Rules))
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package save
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/response"
+	"linkify/internal/lib/random"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"time"
+)
+
+// maxProtectedItems bounds the password-protected links of a batch. Every
+// password is hashed with bcrypt in the request, which takes tens of
+// milliseconds, so a larger batch would not fit the server write timeout.
+const maxProtectedItems = 10
+
+// BatchRequest lists the links to create at once.
+type BatchRequest struct {
+	Items []Request `json:"items"`
+}
+
+// BatchItem is the outcome of a single link of a batch. Status holds the
+// HTTP status the link would get from POST /api/url.
+type BatchItem struct {
+	Status    int        `json:"status"`
+	Alias     string     `json:"alias,omitempty"`
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// BatchResponse holds the results in the order of the requested items.
+type BatchResponse struct {
+	response.Response `swaggertype:"object,string"`
+
+	CreatedAt time.Time   `json:"created_at"`
+	Items     []BatchItem `json:"items"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=BatchSaver
+type BatchSaver interface {
+	SaveBatch(links []storage.Link) ([]error, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=MetricsBatchSaver
+type MetricsBatchSaver interface {
+	IncLinksCreated()
+	ObserveBatchSize(operation string, size int)
+}
+
+// NewBatch handles the creation of several URLs in one request.
+// @Summary      Save URLs
+// @Description  Saves up to the configured limit of URLs at once. Every item is validated and saved independently and reports its own status. At most 10 items may have a password
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body BatchRequest true "URLs to save"
+// @Success      200  {object}  BatchResponse  "Per-item results"
+// @Failure      400  {object}  response.Response  "Invalid request or too many items"
+// @Failure      401  {object}  response.Response  "Unauthorized"
+// @Failure      500  {object}  response.Response  "Internal server error"
+// @Router       /api/urls/batch [post]
+func NewBatch(log *zap.SugaredLogger, saver BatchSaver, rules AliasRules, screener Screener, limit int, m MetricsBatchSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("Unauthorized"))
+			return
+		}
+
+		var req BatchRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+		if len(req.Items) == 0 || len(req.Items) > limit {
+			log.Infow("invalid batch size", "size", len(req.Items))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("batch must contain from 1 to %d items", limit)))
+			return
+		}
+		if protected := countProtected(req.Items); protected > maxProtectedItems {
+			log.Infow("too many protected items", "count", protected)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("batch may contain at most %d items with a password", maxProtectedItems)))
+			return
+		}
+		m.ObserveBatchSize("create", len(req.Items))
+
+		now := time.Now()
+		validate := validator.New()
+		screener.Register(validate)
+		items := make([]BatchItem, len(req.Items))
+		links := make([]storage.Link, len(req.Items))
+		generated := make([]bool, len(req.Items))
+		var pending []int
+		for i, item := range req.Items {
+			items[i] = BatchItem{URL: item.URL, Alias: item.Alias}
+			link, err := batchLink(r.Context(), validate, item, ownerID, now, rules)
+			if err != nil {
+				items[i].Status = http.StatusBadRequest
+				items[i].Error = err.Error()
+				continue
+			}
+			links[i] = link
+			generated[i] = link.Alias == ""
+			pending = append(pending, i)
+		}
+
+		if err := saveBatch(log, saver, links, generated, pending, rules.Length, items, m); err != nil {
+			log.Error("failed to save urls", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to save urls"))
+			return
+		}
+
+		log.Infow("batch of URLs processed", "size", len(items), "owner_id", ownerID)
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, BatchResponse{
+			Response:  response.OK(),
+			CreatedAt: now,
+			Items:     items,
+		})
+	}
+}
+
+func countProtected(items []Request) int {
+	n := 0
+	for _, item := range items {
+		if item.Password != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// saveBatch saves links[i] for every i in pending and records the outcome in
+// items[i]. Links with generated aliases get a new alias after a collision.
+func saveBatch(
+	log *zap.SugaredLogger,
+	saver BatchSaver,
+	links []storage.Link,
+	generated []bool,
+	pending []int,
+	aliasLength int,
+	items []BatchItem,
+	m MetricsSaver,
+) error {
+	const maxAttempts = 5
+	for attempt := 0; attempt < maxAttempts && len(pending) > 0; attempt++ {
+		batch := make([]storage.Link, len(pending))
+		for j, i := range pending {
+			if generated[i] {
+				links[i].Alias = random.NewRandomString(aliasLength)
+			}
+			batch[j] = links[i]
+		}
+		errs, err := saver.SaveBatch(batch)
+		if err != nil {
+			return err
+		}
+
+		var retry []int
+		for j, i := range pending {
+			switch {
+			case errs[j] == nil:
+				items[i].Status = http.StatusCreated
+				items[i].Alias = links[i].Alias
+				items[i].ExpiresAt = links[i].ExpiresAt
+				m.IncLinksCreated()
+			case errors.Is(errs[j], storage.ErrAliasExists) && generated[i]:
+				retry = append(retry, i)
+			case errors.Is(errs[j], storage.ErrAliasExists):
+				items[i].Status = http.StatusConflict
+				items[i].Error = "alias already exists"
+			default:
+				items[i].Status = http.StatusInternalServerError
+				items[i].Error = "failed to save url"
+			}
+		}
+		if len(retry) > 0 {
+			log.Infow("alias collisions", "attempt", attempt+1, "count", len(retry))
+		}
+		pending = retry
+	}
+	for _, i := range pending {
+		items[i].Status = http.StatusInternalServerError
+		items[i].Error = "failed to generate unique alias"
+	}
+	return nil
+}
+
+func batchLink(ctx context.Context, validate *validator.Validate, req Request, ownerID string, now time.Time, rules AliasRules) (storage.Link, error) {
+	if err := validate.StructCtx(ctx, req); err != nil {
+		var validateErrs validator.ValidationErrors
+		errors.As(err, &validateErrs)
+		return storage.Link{}, errors.New(response.ValidateError(validateErrs).Error)
+	}
+	expiresAt, err := expiryFromRequest(req, now)
+	if err != nil {
+		return storage.Link{}, err
+	}
+	if err = validateWindow(req.ActiveFrom, req.ActiveUntil, now); err != nil {
+		return storage.Link{}, err
+	}
+	if req.Alias != "" {
+		if err = validateAlias(req.Alias, rules); err != nil {
+			return storage.Link{}, err
+		}
+	}
+	passwordHash, err := hashPassword(req.Password)
+	if err != nil {
+		return storage.Link{}, errors.New("failed to hash password")
+	}
+	return storage.Link{
+		Alias:        req.Alias,
+		URL:          req.URL,
+		OwnerID:      ownerID,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+		Protected:    passwordHash != nil,
+		PasswordHash: passwordHash,
+		MaxClicks:    req.MaxClicks,
+		ActiveFrom:   req.ActiveFrom,
+		ActiveUntil:  req.ActiveUntil,
+		ForwardQuery: req.ForwardQuery,
+		UTM:          req.UTM,
+		RedirectType: req.RedirectType,
+		Title:        req.Title,
+		Interstitial: req.Interstitial,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package save_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/save"
+	mocker "linkify/internal/transport/handlers/url/save/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchSaveHandler(t *testing.T) {
+	rules := save.AliasRules{Length: 6, MinLength: 3, MaxLength: 32}
+	const ownerID = "42"
+
+	newRequest := func(t *testing.T, body string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/urls/batch", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		return req.WithContext(auth.WithUser(req.Context(), ownerID, "user@example.com", false))
+	}
+
+	t.Run("Per-item results", func(t *testing.T) {
+		saverMock := mocker.NewBatchSaver(t)
+		metricsMock := mocker.NewMetricsBatchSaver(t)
+		metricsMock.On("ObserveBatchSize", "create", 4).Once()
+		metricsMock.On("IncLinksCreated").Twice()
+
+		first := true
+		saverMock.On("SaveBatch", mock.AnythingOfType("[]storage.Link")).
+			Return(func(links []storage.Link) []error {
+				if first {
+					first = false
+					require.Len(t, links, 3)
+					require.Equal(t, "taken", links[1].Alias)
+					require.Equal(t, ownerID, links[0].OwnerID)
+					// the generated alias of the last link collides once
+					return []error{nil, storage.ErrAliasExists, storage.ErrAliasExists}
+				}
+				require.Len(t, links, 1)
+				return []error{nil}
+			}, nil).
+			Twice()
+
+		handler := save.NewBatch(zapdiscard.New(), saverMock, rules, newScreener(t), 10, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, `{"items":[
+			{"url":"https://a.example.com","alias":"custom"},
+			{"url":"https://b.example.com","alias":"taken"},
+			{"url":"not a url"},
+			{"url":"https://c.example.com"}
+		]}`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp save.BatchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 4)
+		require.Equal(t, http.StatusCreated, resp.Items[0].Status)
+		require.Equal(t, "custom", resp.Items[0].Alias)
+		require.Equal(t, http.StatusConflict, resp.Items[1].Status)
+		require.Equal(t, "alias already exists", resp.Items[1].Error)
+		require.Equal(t, http.StatusBadRequest, resp.Items[2].Status)
+		require.Equal(t, "field URL is not a valid URL", resp.Items[2].Error)
+		require.Equal(t, http.StatusCreated, resp.Items[3].Status)
+		require.Len(t, resp.Items[3].Alias, rules.Length)
+	})
+
+	t.Run("Too many protected items", func(t *testing.T) {
+		handler := save.NewBatch(zapdiscard.New(), mocker.NewBatchSaver(t), rules, newScreener(t), 100, mocker.NewMetricsBatchSaver(t))
+		item := `{"url":"https://a.example.com","password":"secret"}`
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, `{"items":[`+strings.Repeat(item+",", 10)+item+`]}`))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "at most 10 items with a password")
+	})
+
+	t.Run("Too many items", func(t *testing.T) {
+		handler := save.NewBatch(zapdiscard.New(), mocker.NewBatchSaver(t), rules, newScreener(t), 1, mocker.NewMetricsBatchSaver(t))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, `{"items":[{"url":"https://a.example.com"},{"url":"https://b.example.com"}]}`))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		saverMock := mocker.NewBatchSaver(t)
+		metricsMock := mocker.NewMetricsBatchSaver(t)
+		metricsMock.On("ObserveBatchSize", "create", 1).Once()
+		saverMock.On("SaveBatch", mock.AnythingOfType("[]storage.Link")).
+			Return(nil, errors.New("unexpected error")).
+			Once()
+
+		handler := save.NewBatch(zapdiscard.New(), saverMock, rules, newScreener(t), 10, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, `{"items":[{"url":"https://a.example.com"}]}`))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
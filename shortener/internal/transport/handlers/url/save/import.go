@@ -0,0 +1,215 @@
+package save
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"io"
+	"linkify/internal/lib/api/response"
+	"linkify/internal/lib/linkfile"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"time"
+)
+
+// maxImportSize bounds the size of an imported file.
+const maxImportSize = 32 << 20
+
+// ImportRow is the outcome of a single record of an imported file.
+type ImportRow struct {
+	Line int `json:"line"`
+	BatchItem
+}
+
+// ImportResponse holds the results in the order of the records.
+type ImportResponse struct {
+	response.Response `swaggertype:"object,string"`
+
+	Imported int         `json:"imported"`
+	Failed   int         `json:"failed"`
+	Items    []ImportRow `json:"items"`
+}
+
+// NewImport handles importing links from a CSV or JSON Lines file as
+// produced by the export.
+// @Summary      Import URLs
+// @Description  Saves the links of a CSV or JSON Lines file, keeping their aliases and creation dates. Records without an alias get a generated one. Every record reports its own status
+// @Tags         url
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        format  query     string  false  "File format: csv (default) or jsonl"
+// @Success      200     {object}  ImportResponse  "Per-record results"
+// @Failure      400     {object}  ImportResponse  "Invalid file; records before the error are imported"
+// @Failure      401     {object}  response.Response  "Unauthorized"
+// @Failure      500     {object}  ImportResponse  "Internal server error"
+// @Router       /api/urls/import [post]
+func NewImport(log *zap.SugaredLogger, saver BatchSaver, rules AliasRules, screener Screener, chunkSize int, m MetricsBatchSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("Unauthorized"))
+			return
+		}
+		format, err := linkfile.ParseFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			log.Infow("invalid import format", "format", r.URL.Query().Get("format"))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		// A large import may outlive the server write timeout.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+		now := time.Now()
+		validate := validator.New()
+		screener.Register(validate)
+		reader := linkfile.NewReader(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+		var (
+			rows  []ImportRow
+			chunk chunkState
+		)
+		flush := func() error {
+			err := saveBatch(log, saver, chunk.links, chunk.generated, chunk.pending, rules.Length, chunk.items, m)
+			for i, item := range chunk.items {
+				if item.Status == 0 {
+					item.Status, item.Error = http.StatusInternalServerError, "failed to save url"
+				}
+				rows = append(rows, ImportRow{Line: chunk.lines[i], BatchItem: item})
+			}
+			chunk = chunkState{}
+			return err
+		}
+
+		status, readErr := http.StatusOK, ""
+		var saveErr error
+		for saveErr == nil {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var recordErr *linkfile.RecordError
+			switch {
+			case errors.As(err, &recordErr):
+				chunk.add(recordErr.Line, BatchItem{Status: http.StatusBadRequest, Error: recordErr.Err.Error()}, nil)
+			case err != nil:
+				log.Infow("failed to read import", "error", err)
+				status, readErr = http.StatusBadRequest, err.Error()
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					status, readErr = http.StatusRequestEntityTooLarge, "file is too large"
+				}
+			default:
+				item := BatchItem{URL: record.URL, Alias: record.Alias}
+				link, err := importedLink(r.Context(), validate, record, ownerID, now, rules)
+				if err != nil {
+					item.Status, item.Error = http.StatusBadRequest, err.Error()
+					chunk.add(reader.Line(), item, nil)
+				} else {
+					chunk.add(reader.Line(), item, &link)
+				}
+			}
+			if readErr != "" {
+				break
+			}
+			if len(chunk.items) >= chunkSize {
+				saveErr = flush()
+			}
+		}
+		if saveErr == nil && len(chunk.items) > 0 {
+			saveErr = flush()
+		}
+		m.ObserveBatchSize("import", len(rows))
+
+		res := ImportResponse{Response: response.OK(), Items: rows}
+		for _, row := range rows {
+			if row.Status == http.StatusCreated {
+				res.Imported++
+			} else {
+				res.Failed++
+			}
+		}
+		switch {
+		case saveErr != nil:
+			log.Error("failed to import urls", zap.Error(saveErr))
+			res.Response = response.Error("failed to import urls")
+			status = http.StatusInternalServerError
+		case readErr != "":
+			res.Response = response.Error(readErr)
+		}
+
+		log.Infow("urls imported", "imported", res.Imported, "failed", res.Failed, "owner_id", ownerID)
+		render.Status(r, status)
+		render.JSON(w, r, res)
+	}
+}
+
+// chunkState collects the records saved together by a single SaveBatch call.
+type chunkState struct {
+	lines     []int
+	items     []BatchItem
+	links     []storage.Link
+	generated []bool
+	pending   []int
+}
+
+// add appends a record; link is nil for a record that failed validation.
+func (c *chunkState) add(line int, item BatchItem, link *storage.Link) {
+	c.lines = append(c.lines, line)
+	c.items = append(c.items, item)
+	if link == nil {
+		c.links = append(c.links, storage.Link{})
+		c.generated = append(c.generated, false)
+		return
+	}
+	c.pending = append(c.pending, len(c.links))
+	c.links = append(c.links, *link)
+	c.generated = append(c.generated, link.Alias == "")
+}
+
+// importedURL screens the destination of an imported link like Request.URL.
+type importedURL struct {
+	URL string `validate:"allowed_scheme,public_host,allowed_domain,not_self,safe_url"`
+}
+
+func importedLink(ctx context.Context, validate *validator.Validate, record storage.ExportedLink, ownerID string, now time.Time, rules AliasRules) (storage.Link, error) {
+	if err := validate.Var(record.URL, "required,url"); err != nil {
+		return storage.Link{}, errors.New("url is not a valid URL")
+	}
+	if err := validate.StructCtx(ctx, importedURL{URL: record.URL}); err != nil {
+		var validateErrs validator.ValidationErrors
+		errors.As(err, &validateErrs)
+		return storage.Link{}, errors.New(response.ValidateError(validateErrs).Error)
+	}
+	if record.Alias != "" {
+		if err := validateAlias(record.Alias, rules); err != nil {
+			return storage.Link{}, err
+		}
+	}
+	if record.ExpiresAt != nil && !record.ExpiresAt.After(now) {
+		return storage.Link{}, errors.New("link has already expired")
+	}
+	createdAt := record.CreatedAt
+	if createdAt.IsZero() || createdAt.After(now) {
+		createdAt = now
+	}
+	return storage.Link{
+		Alias:     record.Alias,
+		URL:       record.URL,
+		OwnerID:   ownerID,
+		CreatedAt: createdAt,
+		ExpiresAt: record.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package save_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/save"
+	mocker "linkify/internal/transport/handlers/url/save/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportHandler(t *testing.T) {
+	rules := save.AliasRules{Length: 6, MinLength: 3, MaxLength: 32}
+	const ownerID = "42"
+
+	newRequest := func(t *testing.T, query, body string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/urls/import"+query, strings.NewReader(body))
+		require.NoError(t, err)
+		return req.WithContext(auth.WithUser(req.Context(), ownerID, "user@example.com", false))
+	}
+
+	t.Run("CSV in chunks", func(t *testing.T) {
+		saverMock := mocker.NewBatchSaver(t)
+		metricsMock := mocker.NewMetricsBatchSaver(t)
+		metricsMock.On("IncLinksCreated").Twice()
+		metricsMock.On("ObserveBatchSize", "import", 4).Once()
+		createdAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+		saverMock.On("SaveBatch", mock.MatchedBy(func(links []storage.Link) bool {
+			return len(links) == 1 && links[0].Alias == "promo"
+		})).
+			Return(func(links []storage.Link) []error {
+				require.True(t, createdAt.Equal(links[0].CreatedAt))
+				require.Equal(t, ownerID, links[0].OwnerID)
+				return []error{nil}
+			}, nil).
+			Once()
+		saverMock.On("SaveBatch", mock.MatchedBy(func(links []storage.Link) bool {
+			return len(links) == 2
+		})).
+			Return([]error{storage.ErrAliasExists, nil}, nil).
+			Once()
+
+		body := "alias,url,created_at,expires_at,clicks\n" +
+			"promo,https://example.com/a,2024-05-01T00:00:00Z,,12\n" +
+			"bad,not a url,,,\n" +
+			"taken,https://example.com/b,,,\n" +
+			",https://example.com/c,,,\n"
+		handler := save.NewImport(zapdiscard.New(), saverMock, rules, newScreener(t), 2, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, "", body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp save.ImportResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, 2, resp.Imported)
+		require.Equal(t, 2, resp.Failed)
+		require.Len(t, resp.Items, 4)
+		require.Equal(t, 2, resp.Items[0].Line)
+		require.Equal(t, http.StatusCreated, resp.Items[0].Status)
+		require.Equal(t, http.StatusBadRequest, resp.Items[1].Status)
+		require.Equal(t, 4, resp.Items[2].Line)
+		require.Equal(t, http.StatusConflict, resp.Items[2].Status)
+		require.Equal(t, "alias already exists", resp.Items[2].Error)
+		require.Equal(t, http.StatusCreated, resp.Items[3].Status)
+		require.Len(t, resp.Items[3].Alias, rules.Length)
+	})
+
+	t.Run("JSON Lines", func(t *testing.T) {
+		saverMock := mocker.NewBatchSaver(t)
+		metricsMock := mocker.NewMetricsBatchSaver(t)
+		metricsMock.On("ObserveBatchSize", "import", 2).Once()
+		metricsMock.On("IncLinksCreated").Once()
+		saverMock.On("SaveBatch", mock.AnythingOfType("[]storage.Link")).
+			Return([]error{nil}, nil).
+			Once()
+
+		body := `{"alias":"promo","url":"https://example.com","expires_at":"2000-01-01T00:00:00Z"}` + "\n" +
+			`{"alias":"fresh","url":"https://example.com"}` + "\n"
+		handler := save.NewImport(zapdiscard.New(), saverMock, rules, newScreener(t), 100, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, "?format=jsonl", body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp save.ImportResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, "link has already expired", resp.Items[0].Error)
+		require.Equal(t, http.StatusCreated, resp.Items[1].Status)
+	})
+
+	t.Run("Invalid header", func(t *testing.T) {
+		metricsMock := mocker.NewMetricsBatchSaver(t)
+		metricsMock.On("ObserveBatchSize", "import", 0).Once()
+		handler := save.NewImport(zapdiscard.New(), mocker.NewBatchSaver(t), rules, newScreener(t), 100, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, "", "alias\npromo\n"))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		saverMock := mocker.NewBatchSaver(t)
+		metricsMock := mocker.NewMetricsBatchSaver(t)
+		metricsMock.On("ObserveBatchSize", "import", 1).Once()
+		saverMock.On("SaveBatch", mock.AnythingOfType("[]storage.Link")).
+			Return(nil, errors.New("unexpected error")).
+			Once()
+
+		handler := save.NewImport(zapdiscard.New(), saverMock, rules, newScreener(t), 100, metricsMock)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(t, "", "url\nhttps://example.com\n"))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		var resp save.ImportResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, "failed to import urls", resp.Error)
+		require.Equal(t, 1, resp.Failed)
+	})
+}
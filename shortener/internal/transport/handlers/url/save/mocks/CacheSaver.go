@@ -7,6 +7,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	storage "linkify/internal/storage"
+
 	time "time"
 )
 
@@ -15,17 +17,17 @@ type CacheSaver struct {
 	mock.Mock
 }
 
-// Set provides a mock function with given fields: ctx, key, value, expiration
-func (_m *CacheSaver) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
-	ret := _m.Called(ctx, key, value, expiration)
+// Set provides a mock function with given fields: ctx, key, link, expiration
+func (_m *CacheSaver) Set(ctx context.Context, key string, link storage.Link, expiration time.Duration) error {
+	ret := _m.Called(ctx, key, link, expiration)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Set")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) error); ok {
-		r0 = rf(ctx, key, value, expiration)
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Link, time.Duration) error); ok {
+		r0 = rf(ctx, key, link, expiration)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -5,7 +5,7 @@ package mocks
 import (
 	mock "github.com/stretchr/testify/mock"
 
-	time "time"
+	storage "linkify/internal/storage"
 )
 
 // URLSaver is an autogenerated mock type for the URLSaver type
@@ -13,17 +13,17 @@ type URLSaver struct {
 	mock.Mock
 }
 
-// Save provides a mock function with given fields: urlToSave, alias, createdAt
-func (_m *URLSaver) Save(urlToSave string, alias string, createdAt time.Time) error {
-	ret := _m.Called(urlToSave, alias, createdAt)
+// Save provides a mock function with given fields: link
+func (_m *URLSaver) Save(link storage.Link) error {
+	ret := _m.Called(link)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Save")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, time.Time) error); ok {
-		r0 = rf(urlToSave, alias, createdAt)
+	if rf, ok := ret.Get(0).(func(storage.Link) error); ok {
+		r0 = rf(link)
 	} else {
 		r0 = ret.Error(0)
 	}
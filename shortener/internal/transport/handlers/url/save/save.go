@@ -3,24 +3,60 @@ package save
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 	"linkify/internal/lib/api/response"
 	"linkify/internal/lib/random"
 	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
 
 	"github.com/go-chi/render"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// Request describes a link to create. ExpiresAt and TTLSeconds are mutually
+// exclusive ways to limit the link lifetime; without them it never expires.
+// TTLSeconds is at most ten years, which keeps it from overflowing a
+// time.Duration. A link with a Password asks for it before redirecting.
+// MaxClicks limits how many times the link may be followed; 1 makes it
+// single-use. URL is screened by the checks of the Screener.
 type Request struct {
-	URL string `json:"url" validate:"required,url"`
+	URL        string     `json:"url" validate:"required,url,allowed_scheme,public_host,allowed_domain,not_self,safe_url"`
+	Alias      string     `json:"alias,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	TTLSeconds int64      `json:"ttl_seconds,omitempty" validate:"gte=0,max=315360000"`
+	Password   string     `json:"password,omitempty" validate:"omitempty,min=4,max=72"`
+	MaxClicks  int        `json:"max_clicks,omitempty" validate:"gte=0"`
+	// ActiveFrom and ActiveUntil limit when the link redirects.
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	// ForwardQuery passes the visitor's query string on to URL and UTM
+	// parameters are added to it on every redirect.
+	ForwardQuery bool         `json:"forward_query,omitempty"`
+	UTM          *storage.UTM `json:"utm,omitempty"`
+	// RedirectType is the redirect status, the service default if omitted.
+	RedirectType int `json:"redirect_type,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	// Title is shown on the preview page; Interstitial shows that page
+	// before every redirect.
+	Title        string `json:"title,omitempty" validate:"max=200"`
+	Interstitial bool   `json:"interstitial,omitempty"`
+}
+
+// AliasRules configures generated aliases and the validation of custom ones.
+type AliasRules struct {
+	Length    int
+	MinLength int
+	MaxLength int
+	Reserved  []string
 }
 
 // Response represents the response structure for the save handler.
-// @Description Response contains the status, alias, and creation time of the saved URL.
+// @Description Response contains the status, alias, creation and expiration time of the saved URL.
 type Response struct {
 	// Status is the response status.
 	// @Example success
@@ -30,17 +66,33 @@ type Response struct {
 
 	Alias string `json:"alias"`
 
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+	ExpiresAt    *time.Time   `json:"expires_at,omitempty"`
+	Protected    bool         `json:"protected,omitempty"`
+	MaxClicks    int          `json:"max_clicks,omitempty"`
+	ActiveFrom   *time.Time   `json:"active_from,omitempty"`
+	ActiveUntil  *time.Time   `json:"active_until,omitempty"`
+	ForwardQuery bool         `json:"forward_query,omitempty"`
+	UTM          *storage.UTM `json:"utm,omitempty"`
+	RedirectType int          `json:"redirect_type,omitempty"`
+	Title        string       `json:"title,omitempty"`
+	Interstitial bool         `json:"interstitial,omitempty"`
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLSaver
 type URLSaver interface {
-	Save(urlToSave string, alias string, createdAt time.Time) error
+	Save(link storage.Link) error
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheSaver
 type CacheSaver interface {
-	Set(ctx context.Context, key string, value string, expiration time.Duration) error
+	Set(ctx context.Context, key string, link storage.Link, expiration time.Duration) error
+}
+
+// Screener adds the checks of link destinations to a validator. See
+// screening.Screener.
+type Screener interface {
+	Register(v *validator.Validate)
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=MetricsSaver
@@ -50,7 +102,7 @@ type MetricsSaver interface {
 
 // New handles the save of a URL by its alias.
 // @Summary      Save URL
-// @Description  Saves a URL and generates a unique alias
+// @Description  Saves a URL under the requested custom alias or generates a unique one. URLs with a forbidden scheme, private or local hosts, blocked domains, links to the service itself and URLs reported as malicious are rejected
 // @Tags         url
 // @Accept       json
 // @Produce      json
@@ -59,14 +111,23 @@ type MetricsSaver interface {
 // @Success      201  {object}  Response  "URL saved successfully"
 // @Failure      400  {object}  response.Response  "Invalid request or validation error"
 // @Failure      401  {object}  response.Response  "Unauthorized"
+// @Failure      409  {object}  response.Response  "Alias already exists"
 // @Failure      500  {object}  response.Response  "Internal server error"
 // @Router       /api/url [post]
-func New(log *zap.SugaredLogger, urlSaver URLSaver, CacheSaver CacheSaver, aliasLength int, m MetricsSaver) http.HandlerFunc {
+func New(log *zap.SugaredLogger, urlSaver URLSaver, CacheSaver CacheSaver, rules AliasRules, screener Screener, m MetricsSaver) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log := log.With(
 			"request_id", middleware.GetReqID(r.Context()),
 		)
 
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("Unauthorized"))
+			return
+		}
+
 		var req Request
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil {
@@ -77,9 +138,12 @@ func New(log *zap.SugaredLogger, urlSaver URLSaver, CacheSaver CacheSaver, alias
 			return
 		}
 
-		log.Infow("request body decoded", "request", req)
+		// The request itself is not logged, as it carries the password.
+		log.Infow("request body decoded", "url", req.URL, "alias", req.Alias, "protected", req.Password != "")
 
-		if err = validator.New().Struct(req); err != nil {
+		validate := validator.New()
+		screener.Register(validate)
+		if err = validate.StructCtx(r.Context(), req); err != nil {
 			var validateErrs validator.ValidationErrors
 			errors.As(err, &validateErrs)
 
@@ -89,33 +153,104 @@ func New(log *zap.SugaredLogger, urlSaver URLSaver, CacheSaver CacheSaver, alias
 			return
 		}
 		now := time.Now()
-		alias, err := generateUniqueAlias(log, urlSaver, req.URL, aliasLength, now)
+		expiresAt, err := expiryFromRequest(req, now)
 		if err != nil {
-			log.Error("failed to generate unique alias after multiple attempts", zap.Error(err))
+			log.Infow("invalid expiration", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		if err = validateWindow(req.ActiveFrom, req.ActiveUntil, now); err != nil {
+			log.Infow("invalid activation window", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		link := storage.Link{
+			Alias:        req.Alias,
+			URL:          req.URL,
+			OwnerID:      ownerID,
+			CreatedAt:    now,
+			ExpiresAt:    expiresAt,
+			MaxClicks:    req.MaxClicks,
+			ActiveFrom:   req.ActiveFrom,
+			ActiveUntil:  req.ActiveUntil,
+			ForwardQuery: req.ForwardQuery,
+			UTM:          req.UTM,
+			RedirectType: req.RedirectType,
+			Title:        req.Title,
+			Interstitial: req.Interstitial,
+		}
+		if link.Alias != "" {
+			if err = validateAlias(link.Alias, rules); err != nil {
+				log.Infow("invalid custom alias", "alias", link.Alias, "error", err)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
+		}
+		// bcrypt is expensive, so the password is hashed only once the
+		// request has passed every other check.
+		if link.PasswordHash, err = hashPassword(req.Password); err != nil {
+			log.Error("failed to hash password", zap.Error(err))
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, response.Error("failed to generate unique alias"))
+			render.JSON(w, r, response.Error("failed to save url"))
 			return
 		}
-		if err := CacheSaver.Set(r.Context(), alias, req.URL, time.Hour); err != nil {
-			log.Errorw("failed to save in cache", "alias", alias, "error", err)
+		link.Protected = link.PasswordHash != nil
+		if link.Alias != "" {
+			if err = urlSaver.Save(link); err != nil {
+				if errors.Is(err, storage.ErrAliasExists) {
+					log.Infow("alias already exists", "alias", link.Alias)
+					render.Status(r, http.StatusConflict)
+					render.JSON(w, r, response.Error("alias already exists"))
+					return
+				}
+				log.Error("failed to save url", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to save url"))
+				return
+			}
+		} else {
+			link.Alias, err = generateUniqueAlias(log, urlSaver, link, rules.Length)
+			if err != nil {
+				log.Error("failed to generate unique alias after multiple attempts", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to generate unique alias"))
+				return
+			}
+		}
+		if err := CacheSaver.Set(r.Context(), link.Alias, link, time.Hour); err != nil {
+			log.Errorw("failed to save in cache", "alias", link.Alias, "error", err)
 		}
 		m.IncLinksCreated()
-		log.Infow("new URL added", "url", req.URL)
+		log.Infow("new URL added", "url", req.URL, "owner_id", ownerID)
 		render.Status(r, http.StatusCreated)
 		render.JSON(w, r, Response{
-			Response:  response.OK(),
-			Alias:     alias,
-			CreatedAt: now,
+			Response:     response.OK(),
+			Alias:        link.Alias,
+			CreatedAt:    now,
+			ExpiresAt:    link.ExpiresAt,
+			Protected:    link.Protected,
+			MaxClicks:    link.MaxClicks,
+			ActiveFrom:   link.ActiveFrom,
+			ActiveUntil:  link.ActiveUntil,
+			ForwardQuery: link.ForwardQuery,
+			UTM:          link.UTM,
+			RedirectType: link.RedirectType,
+			Title:        link.Title,
+			Interstitial: link.Interstitial,
 		})
 	}
 }
 
-func generateUniqueAlias(log *zap.SugaredLogger, saver URLSaver, url string, length int, createdAt time.Time) (string, error) {
+func generateUniqueAlias(log *zap.SugaredLogger, saver URLSaver, link storage.Link, length int) (string, error) {
 	const maxAttempts = 5
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		alias := random.NewRandomString(length)
-		err := saver.Save(url, alias, createdAt)
+		link.Alias = alias
+		err := saver.Save(link)
 		if err == nil {
 			return alias, nil
 		}
@@ -129,3 +264,64 @@ func generateUniqueAlias(log *zap.SugaredLogger, saver URLSaver, url string, len
 
 	return "", errors.New("max attempts reached")
 }
+
+func expiryFromRequest(req Request, now time.Time) (*time.Time, error) {
+	switch {
+	case req.ExpiresAt != nil && req.TTLSeconds > 0:
+		return nil, errors.New("only one of expires_at and ttl_seconds may be set")
+	case req.ExpiresAt != nil:
+		if !req.ExpiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		return req.ExpiresAt, nil
+	case req.TTLSeconds > 0:
+		expiresAt := now.Add(time.Duration(req.TTLSeconds) * time.Second)
+		return &expiresAt, nil
+	}
+	return nil, nil
+}
+
+// validateWindow checks that the activation window is not empty and has
+// not already closed.
+func validateWindow(from, until *time.Time, now time.Time) error {
+	if until == nil {
+		return nil
+	}
+	if !until.After(now) {
+		return errors.New("active_until must be in the future")
+	}
+	if from != nil && !until.After(*from) {
+		return errors.New("active_until must be after active_from")
+	}
+	return nil
+}
+
+// hashPassword returns the bcrypt hash of a link password, or nil for an
+// empty one.
+func hashPassword(password string) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+func validateAlias(alias string, rules AliasRules) error {
+	if len(alias) < rules.MinLength || len(alias) > rules.MaxLength {
+		return fmt.Errorf("alias must be between %d and %d characters long", rules.MinLength, rules.MaxLength)
+	}
+	for _, c := range alias {
+		if !isAliasChar(c) {
+			return errors.New("alias may only contain latin letters, digits, '-' and '_'")
+		}
+	}
+	for _, reserved := range rules.Reserved {
+		if strings.EqualFold(alias, reserved) {
+			return errors.New("alias is reserved")
+		}
+	}
+	return nil
+}
+
+func isAliasChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
@@ -7,8 +7,12 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"linkify/internal/config"
+	"linkify/internal/screening"
+	"linkify/internal/storage"
 	"linkify/internal/transport/handlers/url/save"
 	mocker "linkify/internal/transport/handlers/url/save/mocks"
+	"linkify/internal/transport/middleware/auth"
 	"linkify/pkg/logger/zapdiscard"
 	"net/http"
 	"net/http/httptest"
@@ -16,6 +20,15 @@ import (
 	"time"
 )
 
+// newScreener returns a screener blocking private hosts and the host
+// lnk.example of the service.
+func newScreener(t *testing.T) *screening.Screener {
+	t.Helper()
+	screener, err := screening.New(config.Screening{BlockPrivate: true, SelfHosts: []string{"lnk.example"}}, zapdiscard.New())
+	require.NoError(t, err)
+	return screener
+}
+
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -26,6 +39,9 @@ func TestSaveHandler(t *testing.T) {
 		cacheError error
 		cacheURL   string
 		body       string
+		anonymous  bool
+		alias      string
+		expires    bool
 	}{
 		{
 			name:       "Success",
@@ -47,6 +63,27 @@ func TestSaveHandler(t *testing.T) {
 			statusCode: http.StatusBadRequest,
 			body:       fmt.Sprintf(`{"url": "%s"}`, "some invalid URL"),
 		},
+		{
+			name:       "Script URL",
+			url:        "javascript:alert(1)",
+			respError:  "field URL has a scheme that is not allowed",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "javascript:alert(1)"}`,
+		},
+		{
+			name:       "Private address",
+			url:        "http://192.168.1.1/admin",
+			respError:  "field URL points to a private or local address",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "http://192.168.1.1/admin"}`,
+		},
+		{
+			name:       "Link to the service",
+			url:        "https://LNK.example/abc",
+			respError:  "field URL points to this service",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://LNK.example/abc"}`,
+		},
 		{
 			name:       "Save Error",
 			url:        "https://google.com",
@@ -55,6 +92,114 @@ func TestSaveHandler(t *testing.T) {
 			statusCode: http.StatusInternalServerError,
 			body:       fmt.Sprintf(`{"url": "%s"}`, "https://google.com"),
 		},
+		{
+			name:       "Custom alias",
+			url:        "https://google.com",
+			alias:      "spring-sale",
+			statusCode: http.StatusCreated,
+			body:       `{"url": "https://google.com", "alias": "spring-sale"}`,
+		},
+		{
+			name:       "Custom alias exists",
+			url:        "https://google.com",
+			alias:      "spring-sale",
+			respError:  "alias already exists",
+			mockError:  storage.ErrAliasExists,
+			statusCode: http.StatusConflict,
+			body:       `{"url": "https://google.com", "alias": "spring-sale"}`,
+		},
+		{
+			name:       "Custom alias too short",
+			url:        "https://google.com",
+			respError:  "alias must be between 3 and 32 characters long",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "alias": "ab"}`,
+		},
+		{
+			name:       "Custom alias with invalid characters",
+			url:        "https://google.com",
+			respError:  "alias may only contain latin letters, digits, '-' and '_'",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "alias": "spring.sale"}`,
+		},
+		{
+			name:       "Reserved alias",
+			url:        "https://google.com",
+			respError:  "alias is reserved",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "alias": "Swagger"}`,
+		},
+		{
+			name:       "With TTL",
+			url:        "https://google.com",
+			statusCode: http.StatusCreated,
+			body:       `{"url": "https://google.com", "ttl_seconds": 3600}`,
+			expires:    true,
+		},
+		{
+			name:       "With expiration date",
+			url:        "https://google.com",
+			statusCode: http.StatusCreated,
+			body:       fmt.Sprintf(`{"url": "https://google.com", "expires_at": "%s"}`, time.Now().Add(time.Hour).Format(time.RFC3339)),
+			expires:    true,
+		},
+		{
+			name:       "Expiration date in the past",
+			url:        "https://google.com",
+			respError:  "expires_at must be in the future",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "expires_at": "2020-01-01T00:00:00Z"}`,
+		},
+		{
+			name:       "Both TTL and expiration date",
+			url:        "https://google.com",
+			respError:  "only one of expires_at and ttl_seconds may be set",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "ttl_seconds": 60, "expires_at": "2030-01-01T00:00:00Z"}`,
+		},
+		{
+			name:       "Activation window closed",
+			url:        "https://google.com",
+			respError:  "active_until must be in the future",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "active_until": "2020-01-01T00:00:00Z"}`,
+		},
+		{
+			name:       "Empty activation window",
+			url:        "https://google.com",
+			respError:  "active_until must be after active_from",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "active_from": "2030-02-01T00:00:00Z", "active_until": "2030-01-01T00:00:00Z"}`,
+		},
+		{
+			name:       "Invalid redirect type",
+			url:        "https://google.com",
+			respError:  "field RedirectType is not valid",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "redirect_type": 303}`,
+		},
+		{
+			name:       "Negative TTL",
+			url:        "https://google.com",
+			respError:  "field TTLSeconds is not valid",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "ttl_seconds": -1}`,
+		},
+		{
+			name:       "TTL too long",
+			url:        "https://google.com",
+			respError:  "field TTLSeconds is not valid",
+			statusCode: http.StatusBadRequest,
+			body:       `{"url": "https://google.com", "ttl_seconds": 9223372036854775807}`,
+		},
+		{
+			name:       "Unauthorized",
+			url:        "https://google.com",
+			respError:  "Unauthorized",
+			statusCode: http.StatusUnauthorized,
+			body:       fmt.Sprintf(`{"url": "%s"}`, "https://google.com"),
+			anonymous:  true,
+		},
 		{
 			name:       "Invalid JSON",
 			url:        "",
@@ -63,7 +208,17 @@ func TestSaveHandler(t *testing.T) {
 			body:       `{"url": "https://google.com"`,
 		},
 	}
-	const aliasLength = 6
+	const (
+		aliasLength = 6
+		ownerID     = "42"
+	)
+	rules := save.AliasRules{
+		Length:    aliasLength,
+		MinLength: 3,
+		MaxLength: 32,
+		Reserved:  []string{"api", "swagger", "auth", "metrics"},
+	}
+	screener := newScreener(t)
 	t.Parallel()
 	for _, tc := range cases {
 		tc := tc
@@ -76,20 +231,30 @@ func TestSaveHandler(t *testing.T) {
 			metricsSaverMock := mocker.NewMetricsSaver(t)
 			metricsSaverMock.On("IncLinksCreated").Maybe()
 			if tc.respError == "" || tc.mockError != nil {
-				urlSaverMock.On("Save", tc.url, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+				isExpected := func(link storage.Link) bool {
+					return link.URL == tc.url &&
+						link.OwnerID == ownerID &&
+						link.Alias != "" &&
+						(tc.alias == "" || link.Alias == tc.alias) &&
+						(link.ExpiresAt != nil) == tc.expires
+				}
+				urlSaverMock.On("Save", mock.MatchedBy(isExpected)).
 					Return(tc.mockError).
 					Once()
 
 				if tc.mockError == nil {
-					cacheSaverMock.On("Set", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string"), tc.url, mock.AnythingOfType("time.Duration")).
+					cacheSaverMock.On("Set", mock.Anything, mock.AnythingOfType("string"), mock.MatchedBy(isExpected), mock.AnythingOfType("time.Duration")).
 						Return(nil).
 						Once()
 				}
 			}
-			handler := save.New(zapdiscard.New(), urlSaverMock, cacheSaverMock, aliasLength, metricsSaverMock)
+			handler := save.New(zapdiscard.New(), urlSaverMock, cacheSaverMock, rules, screener, metricsSaverMock)
 
 			req, err := http.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(tc.body)))
 			require.NoError(t, err)
+			if !tc.anonymous {
+				req = req.WithContext(auth.WithUser(req.Context(), ownerID, "user@example.com", false))
+			}
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
@@ -108,8 +273,13 @@ func TestSaveHandler(t *testing.T) {
 			cacheSaverMock.AssertExpectations(t)
 			if tc.respError == "" {
 				require.NotEmpty(t, resp.Alias)
-				require.Len(t, resp.Alias, aliasLength)
+				if tc.alias != "" {
+					require.Equal(t, tc.alias, resp.Alias)
+				} else {
+					require.Len(t, resp.Alias, aliasLength)
+				}
 				require.WithinDuration(t, time.Now(), resp.CreatedAt, time.Second)
+				require.Equal(t, tc.expires, resp.ExpiresAt != nil)
 			} else {
 				require.Empty(t, resp.Alias)
 				require.True(t, resp.CreatedAt.IsZero())
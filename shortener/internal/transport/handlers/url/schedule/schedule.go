@@ -0,0 +1,139 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/etag"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"time"
+)
+
+// Request replaces the activation window of a link. An omitted bound
+// leaves the window open on that side.
+type Request struct {
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+}
+
+// Response represents the rescheduled link.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Link
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLUpdater
+type URLUpdater interface {
+	Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// New handles replacing the activation window of a link by its alias.
+// @Summary      Reschedule URL
+// @Description  Sets the window in which a link redirects. Send the ETag of the link in If-Match to change only an unchanged version
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias     path      string   true   "URL alias"
+// @Param        If-Match  header    string   false  "ETag of the version being changed"
+// @Param        request   body      Request  true   "Activation window"
+// @Success      200       {object}  Response
+// @Failure      400       {object}  response.Response  "Invalid request"
+// @Failure      401       {object}  response.Response  "Unauthorized"
+// @Failure      403       {object}  response.Response  "Alias belongs to another user"
+// @Failure      404       {object}  response.Response  "Alias not found"
+// @Failure      412       {object}  response.Response  "Link was modified concurrently"
+// @Failure      500       {object}  response.Response  "Internal server error"
+// @Router       /api/url/{alias}/schedule [put]
+func New(log *zap.SugaredLogger, urlUpdater URLUpdater, cacheDeleter CacheDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		version, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Infow("invalid If-Match header", "header", r.Header.Get("If-Match"))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid If-Match header"))
+			return
+		}
+
+		var req Request
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		if req.ActiveFrom != nil && req.ActiveUntil != nil && !req.ActiveUntil.After(*req.ActiveFrom) {
+			log.Infow("invalid activation window", "active_from", req.ActiveFrom, "active_until", req.ActiveUntil)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("active_until must be after active_from"))
+			return
+		}
+
+		link, err := urlUpdater.Update(alias, ownerID, auth.IsAdminFromContext(r.Context()), version, storage.LinkUpdate{
+			Reschedule:  true,
+			ActiveFrom:  req.ActiveFrom,
+			ActiveUntil: req.ActiveUntil,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrURLNotFound):
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+			case errors.Is(err, storage.ErrForbidden):
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+			case errors.Is(err, storage.ErrVersionConflict):
+				log.Infow("version conflict", "alias", alias, "version", version)
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, resp.Error("link was modified concurrently"))
+			default:
+				log.Error("failed to reschedule url", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to reschedule url"))
+			}
+			return
+		}
+		if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+			log.Error("failed to delete alias from cache", zap.Error(err))
+		}
+
+		log.Infow("url rescheduled", "alias", alias, "active_from", link.ActiveFrom, "active_until", link.ActiveUntil)
+		w.Header().Set("ETag", etag.Format(link.Version))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     *link,
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package schedule_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/lib/api/etag"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/schedule"
+	mocker "linkify/internal/transport/handlers/url/schedule/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScheduleHandler(t *testing.T) {
+	const (
+		alias   = "alias"
+		ownerID = "42"
+	)
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name         string
+		body         string
+		ifMatch      string
+		version      int
+		update       storage.LinkUpdate
+		expectUpdate bool
+		mockError    error
+		statusCode   int
+		respError    string
+	}{
+		{
+			name:         "Success",
+			body:         `{"active_from":"2030-01-01T00:00:00Z","active_until":"2030-02-01T00:00:00Z"}`,
+			update:       storage.LinkUpdate{Reschedule: true, ActiveFrom: &from, ActiveUntil: &until},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Clear window",
+			body:         `{}`,
+			ifMatch:      etag.Format(3),
+			version:      3,
+			update:       storage.LinkUpdate{Reschedule: true},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:       "Empty window",
+			body:       `{"active_from":"2030-02-01T00:00:00Z","active_until":"2030-01-01T00:00:00Z"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "active_until must be after active_from",
+		},
+		{
+			name:       "Invalid If-Match",
+			body:       `{}`,
+			ifMatch:    "abc",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid If-Match header",
+		},
+		{
+			name:         "Version conflict",
+			body:         `{}`,
+			ifMatch:      etag.Format(2),
+			version:      2,
+			update:       storage.LinkUpdate{Reschedule: true},
+			expectUpdate: true,
+			mockError:    storage.ErrVersionConflict,
+			statusCode:   http.StatusPreconditionFailed,
+			respError:    "link was modified concurrently",
+		},
+		{
+			name:         "Not owner",
+			body:         `{}`,
+			update:       storage.LinkUpdate{Reschedule: true},
+			expectUpdate: true,
+			mockError:    storage.ErrForbidden,
+			statusCode:   http.StatusForbidden,
+			respError:    "access denied",
+		},
+		{
+			name:         "Not found",
+			body:         `{}`,
+			update:       storage.LinkUpdate{Reschedule: true},
+			expectUpdate: true,
+			mockError:    storage.ErrURLNotFound,
+			statusCode:   http.StatusNotFound,
+			respError:    "alias not found",
+		},
+		{
+			name:         "Storage error",
+			body:         `{}`,
+			update:       storage.LinkUpdate{Reschedule: true},
+			expectUpdate: true,
+			mockError:    errors.New("unexpected error"),
+			statusCode:   http.StatusInternalServerError,
+			respError:    "failed to reschedule url",
+		},
+	}
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocker.NewURLUpdater(t)
+			cacheDeleterMock := mocker.NewCacheDeleter(t)
+			if tc.expectUpdate {
+				var result *storage.Link
+				if tc.mockError == nil {
+					result = &storage.Link{
+						Alias:       alias,
+						URL:         "https://example.com",
+						OwnerID:     ownerID,
+						Version:     tc.version + 1,
+						ActiveFrom:  tc.update.ActiveFrom,
+						ActiveUntil: tc.update.ActiveUntil,
+					}
+					cacheDeleterMock.On("Delete", mock.Anything, alias).
+						Return(nil).
+						Once()
+				}
+				urlUpdaterMock.On("Update", alias, ownerID, false, tc.version, tc.update).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := schedule.New(zapdiscard.New(), urlUpdaterMock, cacheDeleterMock)
+			req, err := http.NewRequest(http.MethodPut, "/url/"+alias+"/schedule", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, ownerID, "user@example.com", false))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp schedule.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, etag.Format(tc.version+1), rr.Header().Get("ETag"))
+				require.Equal(t, tc.update.ActiveFrom, resp.ActiveFrom)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package stats
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Response represents the click statistics of a link.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.ClickStats
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=StatsGetter
+type StatsGetter interface {
+	ClickStats(alias string, ownerID string, isAdmin bool, filter storage.StatsFilter) (*storage.ClickStats, error)
+}
+
+// New handles the click statistics of a link.
+// @Summary      URL statistics
+// @Description  Returns total clicks, a time series, top referrers and clicks per A/B variant of the link. Only the owner or an admin may read them
+// @Tags         url
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias   path      string  true   "URL alias"
+// @Param        bucket  query     string  false  "Series bucket: hour or day (default day)"
+// @Param        from    query     string  false  "Start of the range (RFC 3339, inclusive)"
+// @Param        to      query     string  false  "End of the range (RFC 3339, exclusive)"
+// @Success      200     {object}  Response
+// @Failure      400     {object}  response.Response  "Invalid request"
+// @Failure      401     {object}  response.Response  "Unauthorized"
+// @Failure      403     {object}  response.Response  "Alias belongs to another user"
+// @Failure      404     {object}  response.Response  "Alias not found"
+// @Failure      500     {object}  response.Response  "Internal server error"
+// @Router       /api/url/{alias}/stats [get]
+func New(log *zap.SugaredLogger, statsGetter StatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+
+		filter, err := parseFilter(r.URL.Query())
+		if err != nil {
+			log.Infow("invalid stats request", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		stats, err := statsGetter.ClickStats(alias, ownerID, auth.IsAdminFromContext(r.Context()), filter)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+				return
+			}
+			if errors.Is(err, storage.ErrForbidden) {
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+				return
+			}
+			log.Error("failed to get stats", zap.Error(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to get stats"))
+			return
+		}
+
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			ClickStats: *stats,
+		})
+	}
+}
+
+func parseFilter(query url.Values) (storage.StatsFilter, error) {
+	filter := storage.StatsFilter{Bucket: storage.BucketDay}
+	switch bucket := query.Get("bucket"); bucket {
+	case "", storage.BucketDay:
+	case storage.BucketHour:
+		filter.Bucket = bucket
+	default:
+		return filter, errors.New("invalid bucket")
+	}
+	var err error
+	if filter.From, err = parseTime(query, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime(query, "to"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseTime(query url.Values, param string) (*time.Time, error) {
+	raw := query.Get(param)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, errors.New("invalid " + param)
+	}
+	return &t, nil
+}
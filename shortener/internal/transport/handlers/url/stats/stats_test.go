@@ -0,0 +1,139 @@
+package stats_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/stats"
+	mocker "linkify/internal/transport/handlers/url/stats/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatsHandler(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clickStats := &storage.ClickStats{
+		Total:        2,
+		Series:       []storage.StatsBucket{{Time: from, Count: 2}},
+		TopReferrers: []storage.ReferrerCount{{Referrer: "https://t.me", Count: 1}},
+	}
+	cases := []struct {
+		name        string
+		query       string
+		filter      storage.StatsFilter
+		isAdmin     bool
+		mockError   error
+		expectStats bool
+		statusCode  int
+		respError   string
+	}{
+		{
+			name:        "Success",
+			filter:      storage.StatsFilter{Bucket: storage.BucketDay},
+			expectStats: true,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "Hourly range",
+			query:       "?bucket=hour&from=2025-01-01T00:00:00Z",
+			filter:      storage.StatsFilter{Bucket: storage.BucketHour, From: &from},
+			expectStats: true,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:        "Admin",
+			filter:      storage.StatsFilter{Bucket: storage.BucketDay},
+			isAdmin:     true,
+			expectStats: true,
+			statusCode:  http.StatusOK,
+		},
+		{
+			name:       "Invalid bucket",
+			query:      "?bucket=week",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid bucket",
+		},
+		{
+			name:       "Invalid range",
+			query:      "?to=tomorrow",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid to",
+		},
+		{
+			name:        "Not owner",
+			filter:      storage.StatsFilter{Bucket: storage.BucketDay},
+			mockError:   storage.ErrForbidden,
+			expectStats: true,
+			statusCode:  http.StatusForbidden,
+			respError:   "access denied",
+		},
+		{
+			name:        "Not found",
+			filter:      storage.StatsFilter{Bucket: storage.BucketDay},
+			mockError:   storage.ErrURLNotFound,
+			expectStats: true,
+			statusCode:  http.StatusNotFound,
+			respError:   "alias not found",
+		},
+		{
+			name:        "Storage error",
+			filter:      storage.StatsFilter{Bucket: storage.BucketDay},
+			mockError:   errors.New("unexpected error"),
+			expectStats: true,
+			statusCode:  http.StatusInternalServerError,
+			respError:   "failed to get stats",
+		},
+	}
+	const (
+		alias   = "alias"
+		ownerID = "42"
+	)
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			statsGetterMock := mocker.NewStatsGetter(t)
+			if tc.expectStats {
+				var result *storage.ClickStats
+				if tc.mockError == nil {
+					result = clickStats
+				}
+				statsGetterMock.On("ClickStats", alias, ownerID, tc.isAdmin, tc.filter).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := stats.New(zapdiscard.New(), statsGetterMock)
+			req, err := http.NewRequest(http.MethodGet, "/url/"+alias+"/stats"+tc.query, nil)
+			require.NoError(t, err)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, ownerID, "user@example.com", tc.isAdmin))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp stats.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, clickStats.Total, resp.Total)
+				require.Len(t, resp.Series, 1)
+				require.Len(t, resp.TopReferrers, 1)
+			}
+		})
+	}
+}
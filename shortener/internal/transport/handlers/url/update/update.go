@@ -0,0 +1,200 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/etag"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"time"
+)
+
+// Request lists the attributes to change. Omitted fields keep their value;
+// NeverExpires removes the expiration date and an empty UTM object removes
+// the UTM parameters. TTLSeconds is bounded like in save.Request.
+type Request struct {
+	URL          *string      `json:"url,omitempty" validate:"omitempty,url,allowed_scheme,public_host,allowed_domain,not_self,safe_url"`
+	ExpiresAt    *time.Time   `json:"expires_at,omitempty"`
+	TTLSeconds   *int64       `json:"ttl_seconds,omitempty" validate:"omitempty,gt=0,max=315360000"`
+	NeverExpires bool         `json:"never_expires,omitempty"`
+	ForwardQuery *bool        `json:"forward_query,omitempty"`
+	UTM          *storage.UTM `json:"utm,omitempty"`
+	// RedirectType 0 restores the service default.
+	RedirectType *int    `json:"redirect_type,omitempty" validate:"omitempty,oneof=0 301 302 307 308"`
+	Title        *string `json:"title,omitempty" validate:"omitempty,max=200"`
+	Interstitial *bool   `json:"interstitial,omitempty"`
+}
+
+// Response represents the updated link.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Link
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLUpdater
+type URLUpdater interface {
+	Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Screener adds the checks of link destinations to a validator. See
+// screening.Screener.
+type Screener interface {
+	Register(v *validator.Validate)
+}
+
+// New handles changing a link by its alias.
+// @Summary      Update URL
+// @Description  Changes the destination, expiration, query forwarding, UTM parameters, redirect type, title or preview mode of a link. Send the ETag of the link in If-Match to update only an unchanged version
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias     path      string   true   "URL alias"
+// @Param        If-Match  header    string   false  "ETag of the version being changed"
+// @Param        request   body      Request  true   "Attributes to change"
+// @Success      200       {object}  Response
+// @Failure      400       {object}  response.Response  "Invalid request"
+// @Failure      401       {object}  response.Response  "Unauthorized"
+// @Failure      403       {object}  response.Response  "Alias belongs to another user"
+// @Failure      404       {object}  response.Response  "Alias not found"
+// @Failure      412       {object}  response.Response  "Link was modified concurrently"
+// @Failure      500       {object}  response.Response  "Internal server error"
+// @Router       /api/url/{alias} [patch]
+func New(log *zap.SugaredLogger, urlUpdater URLUpdater, cacheDeleter CacheDeleter, screener Screener) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		version, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Infow("invalid If-Match header", "header", r.Header.Get("If-Match"))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid If-Match header"))
+			return
+		}
+
+		var req Request
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		validate := validator.New()
+		screener.Register(validate)
+		if err = validate.StructCtx(r.Context(), req); err != nil {
+			var validateErrs validator.ValidationErrors
+			errors.As(err, &validateErrs)
+
+			log.Error("failed to validate request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidateError(validateErrs))
+			return
+		}
+		change, err := linkUpdate(req, time.Now())
+		if err != nil {
+			log.Infow("invalid update", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		link, err := urlUpdater.Update(alias, ownerID, auth.IsAdminFromContext(r.Context()), version, change)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrURLNotFound):
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+			case errors.Is(err, storage.ErrForbidden):
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+			case errors.Is(err, storage.ErrVersionConflict):
+				log.Infow("version conflict", "alias", alias, "version", version)
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, resp.Error("link was modified concurrently"))
+			default:
+				log.Error("failed to update url", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to update url"))
+			}
+			return
+		}
+		if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+			log.Error("failed to delete alias from cache", zap.Error(err))
+		}
+
+		log.Infow("url updated", "alias", alias, "version", link.Version)
+		w.Header().Set("ETag", etag.Format(link.Version))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     *link,
+		})
+	}
+}
+
+func linkUpdate(req Request, now time.Time) (storage.LinkUpdate, error) {
+	change := storage.LinkUpdate{
+		URL:          req.URL,
+		ClearExpiry:  req.NeverExpires,
+		ForwardQuery: req.ForwardQuery,
+		UTM:          req.UTM,
+		RedirectType: req.RedirectType,
+		Title:        req.Title,
+		Interstitial: req.Interstitial,
+	}
+	set := 0
+	if req.NeverExpires {
+		set++
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return change, errors.New("expires_at must be in the future")
+		}
+		change.ExpiresAt = req.ExpiresAt
+		set++
+	}
+	if req.TTLSeconds != nil {
+		expiresAt := now.Add(time.Duration(*req.TTLSeconds) * time.Second)
+		change.ExpiresAt = &expiresAt
+		set++
+	}
+	if set > 1 {
+		return change, errors.New("only one of expires_at, ttl_seconds and never_expires may be set")
+	}
+	if change.URL == nil && change.ExpiresAt == nil && !change.ClearExpiry &&
+		change.ForwardQuery == nil && change.UTM == nil && change.RedirectType == nil &&
+		change.Title == nil && change.Interstitial == nil {
+		return change, errors.New("nothing to update")
+	}
+	return change, nil
+}
@@ -0,0 +1,201 @@
+package update_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/config"
+	"linkify/internal/lib/api/etag"
+	"linkify/internal/screening"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/update"
+	mocker "linkify/internal/transport/handlers/url/update/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	const (
+		alias   = "alias"
+		ownerID = "42"
+		newURL  = "https://example.com/new"
+	)
+	cases := []struct {
+		name         string
+		body         string
+		ifMatch      string
+		version      int
+		isAdmin      bool
+		expectUpdate bool
+		mockError    error
+		statusCode   int
+		respError    string
+	}{
+		{
+			name:         "Success",
+			body:         `{"url":"` + newURL + `"}`,
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Matching version",
+			body:         `{"url":"` + newURL + `"}`,
+			ifMatch:      etag.Format(3),
+			version:      3,
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Admin",
+			body:         `{"never_expires":true}`,
+			isAdmin:      true,
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Version conflict",
+			body:         `{"url":"` + newURL + `"}`,
+			ifMatch:      etag.Format(2),
+			version:      2,
+			expectUpdate: true,
+			mockError:    storage.ErrVersionConflict,
+			statusCode:   http.StatusPreconditionFailed,
+			respError:    "link was modified concurrently",
+		},
+		{
+			name:       "Invalid If-Match",
+			body:       `{"url":"` + newURL + `"}`,
+			ifMatch:    "abc",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid If-Match header",
+		},
+		{
+			name:       "Invalid url",
+			body:       `{"url":"not a url"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field URL is not a valid URL",
+		},
+		{
+			name:       "Loopback url",
+			body:       `{"url":"http://127.0.0.1:8080/admin"}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field URL points to a private or local address",
+		},
+		{
+			name:         "Query options",
+			body:         `{"forward_query":true,"utm":{"utm_source":"newsletter"}}`,
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Redirect type",
+			body:         `{"redirect_type":308}`,
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:       "Invalid redirect type",
+			body:       `{"redirect_type":300}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field RedirectType is not valid",
+		},
+		{
+			name:       "Nothing to update",
+			body:       `{}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "nothing to update",
+		},
+		{
+			name:       "Conflicting expiry",
+			body:       `{"ttl_seconds":60,"never_expires":true}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "only one of expires_at, ttl_seconds and never_expires may be set",
+		},
+		{
+			name:       "TTL too long",
+			body:       `{"ttl_seconds":9223372036854775807}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field TTLSeconds is not valid",
+		},
+		{
+			name:         "Not owner",
+			body:         `{"url":"` + newURL + `"}`,
+			expectUpdate: true,
+			mockError:    storage.ErrForbidden,
+			statusCode:   http.StatusForbidden,
+			respError:    "access denied",
+		},
+		{
+			name:         "Not found",
+			body:         `{"url":"` + newURL + `"}`,
+			expectUpdate: true,
+			mockError:    storage.ErrURLNotFound,
+			statusCode:   http.StatusNotFound,
+			respError:    "alias not found",
+		},
+		{
+			name:         "Storage error",
+			body:         `{"url":"` + newURL + `"}`,
+			expectUpdate: true,
+			mockError:    errors.New("unexpected error"),
+			statusCode:   http.StatusInternalServerError,
+			respError:    "failed to update url",
+		},
+	}
+	screener, err := screening.New(config.Screening{BlockPrivate: true}, zapdiscard.New())
+	require.NoError(t, err)
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocker.NewURLUpdater(t)
+			cacheDeleterMock := mocker.NewCacheDeleter(t)
+			if tc.expectUpdate {
+				var result *storage.Link
+				if tc.mockError == nil {
+					result = &storage.Link{Alias: alias, URL: newURL, OwnerID: ownerID, Version: tc.version + 1}
+					cacheDeleterMock.On("Delete", mock.Anything, alias).
+						Return(nil).
+						Once()
+				}
+				urlUpdaterMock.On("Update", alias, ownerID, tc.isAdmin, tc.version, mock.AnythingOfType("storage.LinkUpdate")).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := update.New(zapdiscard.New(), urlUpdaterMock, cacheDeleterMock, screener)
+			req, err := http.NewRequest(http.MethodPatch, "/url/"+alias, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, ownerID, "user@example.com", tc.isAdmin))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp update.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, newURL, resp.URL)
+				require.Equal(t, etag.Format(tc.version+1), rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package variants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/etag"
+	resp "linkify/internal/lib/api/response"
+	"linkify/internal/storage"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+	"regexp"
+)
+
+// MaxVariants limits the number of destinations of a single link.
+const MaxVariants = 10
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Variant is a destination receiving Weight parts of the traffic. URL is
+// screened like the destination of the link.
+type Variant struct {
+	Name   string `json:"name" validate:"required"`
+	URL    string `json:"url" validate:"required,url,allowed_scheme,public_host,allowed_domain,not_self,safe_url"`
+	Weight int    `json:"weight" validate:"gt=0,lte=10000"`
+}
+
+// Request replaces the A/B variants of a link. An empty list removes them
+// and sends all visitors to the link URL again. With Sticky a visitor keeps
+// the variant chosen on the first visit.
+type Request struct {
+	Variants []Variant `json:"variants" validate:"dive"`
+	Sticky   bool      `json:"sticky,omitempty"`
+}
+
+// Response represents the link with its new variants.
+type Response struct {
+	resp.Response `swaggertype:"object,string"`
+
+	storage.Link
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=URLUpdater
+type URLUpdater interface {
+	Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.50.2 --name=CacheDeleter
+type CacheDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Screener adds the checks of link destinations to a validator. See
+// screening.Screener.
+type Screener interface {
+	Register(v *validator.Validate)
+}
+
+// New handles replacing the A/B variants of a link by its alias.
+// @Summary      Set A/B variants
+// @Description  Splits the traffic of a link across several destinations by weight. Routing rules still take precedence. Variant URLs are screened like link destinations. Send the ETag of the link in If-Match to change only an unchanged version
+// @Tags         url
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        alias     path      string   true   "URL alias"
+// @Param        If-Match  header    string   false  "ETag of the version being changed"
+// @Param        request   body      Request  true   "Weighted destinations"
+// @Success      200       {object}  Response
+// @Failure      400       {object}  response.Response  "Invalid request"
+// @Failure      401       {object}  response.Response  "Unauthorized"
+// @Failure      403       {object}  response.Response  "Alias belongs to another user"
+// @Failure      404       {object}  response.Response  "Alias not found"
+// @Failure      412       {object}  response.Response  "Link was modified concurrently"
+// @Failure      500       {object}  response.Response  "Internal server error"
+// @Router       /api/url/{alias}/variants [put]
+func New(log *zap.SugaredLogger, urlUpdater URLUpdater, cacheDeleter CacheDeleter, screener Screener) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := log.With(
+			"request_id", middleware.GetReqID(r.Context()),
+		)
+		ownerID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			log.Info("user is not authenticated")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("Unauthorized"))
+			return
+		}
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+			return
+		}
+		version, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Infow("invalid If-Match header", "header", r.Header.Get("If-Match"))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid If-Match header"))
+			return
+		}
+
+		var req Request
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+			return
+		}
+		if len(req.Variants) > MaxVariants {
+			log.Infow("too many variants", "count", len(req.Variants))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(fmt.Sprintf("at most %d variants are allowed", MaxVariants)))
+			return
+		}
+		validate := validator.New()
+		screener.Register(validate)
+		if err = validate.StructCtx(r.Context(), req); err != nil {
+			var validateErrs validator.ValidationErrors
+			errors.As(err, &validateErrs)
+
+			log.Error("failed to validate request", zap.Error(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidateError(validateErrs))
+			return
+		}
+		variants, err := toVariants(req.Variants)
+		if err != nil {
+			log.Infow("invalid variants", "error", err)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+			return
+		}
+
+		link, err := urlUpdater.Update(alias, ownerID, auth.IsAdminFromContext(r.Context()), version, storage.LinkUpdate{
+			SetVariants:    true,
+			Variants:       variants,
+			StickyVariants: req.Sticky && len(variants) > 0,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrURLNotFound):
+				log.Infow("alias not found", "alias", alias)
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("alias not found"))
+			case errors.Is(err, storage.ErrForbidden):
+				log.Infow("alias belongs to another user", "alias", alias, "owner_id", ownerID)
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, resp.Error("access denied"))
+			case errors.Is(err, storage.ErrVersionConflict):
+				log.Infow("version conflict", "alias", alias, "version", version)
+				render.Status(r, http.StatusPreconditionFailed)
+				render.JSON(w, r, resp.Error("link was modified concurrently"))
+			default:
+				log.Error("failed to set variants", zap.Error(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to set variants"))
+			}
+			return
+		}
+		if err = cacheDeleter.Delete(r.Context(), alias); err != nil {
+			log.Error("failed to delete alias from cache", zap.Error(err))
+		}
+
+		log.Infow("variants updated", "alias", alias, "variants", len(variants))
+		w.Header().Set("ETag", etag.Format(link.Version))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     *link,
+		})
+	}
+}
+
+// toVariants converts validated request variants. A split needs at least
+// two destinations and names identify variants in cookies and statistics,
+// so they must be unique.
+func toVariants(reqVariants []Variant) ([]storage.Variant, error) {
+	if len(reqVariants) == 1 {
+		return nil, errors.New("at least two variants are required")
+	}
+	variants := make([]storage.Variant, 0, len(reqVariants))
+	seen := make(map[string]struct{}, len(reqVariants))
+	for _, variant := range reqVariants {
+		if !namePattern.MatchString(variant.Name) {
+			return nil, fmt.Errorf("invalid variant name %q", variant.Name)
+		}
+		if _, ok := seen[variant.Name]; ok {
+			return nil, fmt.Errorf("duplicate variant name %q", variant.Name)
+		}
+		seen[variant.Name] = struct{}{}
+		variants = append(variants, storage.Variant{
+			Name:   variant.Name,
+			URL:    variant.URL,
+			Weight: variant.Weight,
+		})
+	}
+	return variants, nil
+}
@@ -0,0 +1,208 @@
+package variants_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"linkify/internal/config"
+	"linkify/internal/lib/api/etag"
+	"linkify/internal/screening"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/url/variants"
+	mocker "linkify/internal/transport/handlers/url/variants/mocks"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVariantsHandler(t *testing.T) {
+	const (
+		alias   = "alias"
+		ownerID = "42"
+	)
+	split := []storage.Variant{
+		{Name: "control", URL: "https://example.com/a", Weight: 70},
+		{Name: "new-landing", URL: "https://example.com/b", Weight: 30},
+	}
+	splitBody := `{"variants":[
+		{"name":"control","url":"https://example.com/a","weight":70},
+		{"name":"new-landing","url":"https://example.com/b","weight":30}
+	],"sticky":true}`
+	cases := []struct {
+		name         string
+		body         string
+		ifMatch      string
+		version      int
+		update       storage.LinkUpdate
+		expectUpdate bool
+		mockError    error
+		statusCode   int
+		respError    string
+	}{
+		{
+			name:         "Success",
+			body:         splitBody,
+			update:       storage.LinkUpdate{SetVariants: true, Variants: split, StickyVariants: true},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:         "Remove variants",
+			body:         `{"variants":[],"sticky":true}`,
+			ifMatch:      etag.Format(4),
+			version:      4,
+			update:       storage.LinkUpdate{SetVariants: true, Variants: []storage.Variant{}},
+			expectUpdate: true,
+			statusCode:   http.StatusOK,
+		},
+		{
+			name:       "Single variant",
+			body:       `{"variants":[{"name":"a","url":"https://example.com/a","weight":1}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "at least two variants are required",
+		},
+		{
+			name:       "Zero weight",
+			body:       `{"variants":[{"name":"a","url":"https://example.com/a","weight":0},{"name":"b","url":"https://example.com/b","weight":1}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field Weight is not valid",
+		},
+		{
+			name:       "Invalid url",
+			body:       `{"variants":[{"name":"a","url":"landing","weight":1},{"name":"b","url":"https://example.com/b","weight":1}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field URL is not a valid URL",
+		},
+		{
+			name:       "Script url",
+			body:       `{"variants":[{"name":"a","url":"javascript:alert(1)","weight":1},{"name":"b","url":"https://example.com/b","weight":1}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "field URL has a scheme that is not allowed",
+		},
+		{
+			name:       "Invalid name",
+			body:       `{"variants":[{"name":"a b","url":"https://example.com/a","weight":1},{"name":"b","url":"https://example.com/b","weight":1}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  `invalid variant name "a b"`,
+		},
+		{
+			name:       "Duplicate name",
+			body:       `{"variants":[{"name":"a","url":"https://example.com/a","weight":1},{"name":"a","url":"https://example.com/b","weight":1}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  `duplicate variant name "a"`,
+		},
+		{
+			name:       "Too many variants",
+			body:       `{"variants":[` + strings.Repeat(`{"name":"a","url":"https://example.com","weight":1},`, variants.MaxVariants) + `{"name":"a","url":"https://example.com","weight":1}]}`,
+			statusCode: http.StatusBadRequest,
+			respError:  "at most 10 variants are allowed",
+		},
+		{
+			name:       "Invalid If-Match",
+			body:       splitBody,
+			ifMatch:    "abc",
+			statusCode: http.StatusBadRequest,
+			respError:  "invalid If-Match header",
+		},
+		{
+			name:         "Version conflict",
+			body:         splitBody,
+			ifMatch:      etag.Format(2),
+			version:      2,
+			update:       storage.LinkUpdate{SetVariants: true, Variants: split, StickyVariants: true},
+			expectUpdate: true,
+			mockError:    storage.ErrVersionConflict,
+			statusCode:   http.StatusPreconditionFailed,
+			respError:    "link was modified concurrently",
+		},
+		{
+			name:         "Not owner",
+			body:         splitBody,
+			update:       storage.LinkUpdate{SetVariants: true, Variants: split, StickyVariants: true},
+			expectUpdate: true,
+			mockError:    storage.ErrForbidden,
+			statusCode:   http.StatusForbidden,
+			respError:    "access denied",
+		},
+		{
+			name:         "Not found",
+			body:         splitBody,
+			update:       storage.LinkUpdate{SetVariants: true, Variants: split, StickyVariants: true},
+			expectUpdate: true,
+			mockError:    storage.ErrURLNotFound,
+			statusCode:   http.StatusNotFound,
+			respError:    "alias not found",
+		},
+		{
+			name:         "Storage error",
+			body:         splitBody,
+			update:       storage.LinkUpdate{SetVariants: true, Variants: split, StickyVariants: true},
+			expectUpdate: true,
+			mockError:    errors.New("unexpected error"),
+			statusCode:   http.StatusInternalServerError,
+			respError:    "failed to set variants",
+		},
+	}
+	screener, err := screening.New(config.Screening{BlockPrivate: true}, zapdiscard.New())
+	require.NoError(t, err)
+	t.Parallel()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocker.NewURLUpdater(t)
+			cacheDeleterMock := mocker.NewCacheDeleter(t)
+			if tc.expectUpdate {
+				var result *storage.Link
+				if tc.mockError == nil {
+					result = &storage.Link{
+						Alias:    alias,
+						URL:      "https://example.com",
+						OwnerID:  ownerID,
+						Version:  tc.version + 1,
+						Variants: tc.update.Variants,
+					}
+					cacheDeleterMock.On("Delete", mock.Anything, alias).
+						Return(nil).
+						Once()
+				}
+				urlUpdaterMock.On("Update", alias, ownerID, false, tc.version, tc.update).
+					Return(result, tc.mockError).
+					Once()
+			}
+
+			handler := variants.New(zapdiscard.New(), urlUpdaterMock, cacheDeleterMock, screener)
+			req, err := http.NewRequest(http.MethodPut, "/url/"+alias+"/variants", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithUser(ctx, ownerID, "user@example.com", false))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+
+			var resp variants.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				require.Equal(t, etag.Format(tc.version+1), rr.Header().Get("ETag"))
+				require.Len(t, resp.Variants, len(tc.update.Variants))
+			}
+		})
+	}
+}
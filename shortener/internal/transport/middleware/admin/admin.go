@@ -0,0 +1,26 @@
+package admin
+
+import (
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/response"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+)
+
+// New lets only admins through. It must run after the auth middleware,
+// which reads the is_admin flag from the auth service.
+func New(log *zap.SugaredLogger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.IsAdminFromContext(r.Context()) {
+				userID, _ := auth.UserIDFromContext(r.Context())
+				log.Infow("admin access denied", "user_id", userID, "path", r.URL.Path)
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response.Error("access denied"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,41 +2,119 @@ package auth
 
 import (
 	"context"
+	"crypto"
+	"errors"
 	"github.com/Killazius/linkify-proto/pkg/api"
 	"github.com/go-chi/render"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"linkify/internal/lib/api/response"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 )
 
 type Client interface {
 	ValidateToken(ctx context.Context, in *api.TokenRequest, opts ...grpc.CallOption) (*api.TokenResponse, error)
 }
 
+// Keys looks up the public keys the auth service signs access tokens with.
+type Keys interface {
+	Key(kid string) (crypto.PublicKey, bool)
+}
+
 type contextKey string
 
 const (
-	userIDKey    contextKey = "userID"
-	userEmailKey contextKey = "userEmail"
+	userIDKey      contextKey = "userID"
+	userEmailKey   contextKey = "userEmail"
+	userIsAdminKey contextKey = "userIsAdmin"
+	userScopesKey  contextKey = "userScopes"
+)
+
+// isAdminHeader is the gRPC response header the auth service uses to report
+// the is_admin flag, since TokenResponse has no field for it.
+const isAdminHeader = "x-is-admin"
+
+// scopesHeader is the gRPC response header listing the comma-separated
+// scopes of a personal access token. JWTs come without it.
+const scopesHeader = "x-token-scopes"
+
+// Places the access token is read from.
+const (
+	SourceHeader = "header"
+	SourceCookie = "cookie"
 )
 
-func New(auth Client, log *zap.SugaredLogger) func(next http.Handler) http.Handler {
+// accessTokenPrefix starts every personal access token. They can be revoked
+// at any time, so they are always checked by the auth service.
+const accessTokenPrefix = "lk_"
+
+// Scopes required by routes, see RequireScope. Personal access tokens
+// carry some of the links scopes and never ScopeAdmin, so admin routes are
+// open to sessions only.
+const (
+	ScopeLinksRead  = "links:read"
+	ScopeLinksWrite = "links:write"
+	ScopeAdmin      = "admin"
+)
+
+// New authenticates requests with the access token found in the first of
+// sources that has one. Both JWTs and personal access tokens are accepted
+// from either source.
+//
+// With keys set, JWTs signed with one of them are verified locally and
+// confirmed with the auth service at most once per recheckInterval, which
+// also refreshes the admin flag of the token. Other JWTs and personal
+// access tokens are checked by the auth service on every request.
+// The scopes of a personal access token are only recorded in the context;
+// routes enforce them with RequireScope.
+func New(auth Client, log *zap.SugaredLogger, sources []string, keys Keys, recheckInterval time.Duration) func(next http.Handler) http.Handler {
+	checker := newRechecker(auth, log, recheckInterval)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("access_token")
-			if err != nil {
-				log.Debug("Access token cookie not found")
+			token, ok := accessToken(r, sources)
+			if !ok {
+				log.Debug("Access token not found")
 				w.WriteHeader(http.StatusUnauthorized)
 				render.JSON(w, r, response.Error("Unauthorized"))
 				return
 			}
 
+			if keys != nil && !strings.HasPrefix(token, accessTokenPrefix) {
+				user, err := verify(token, keys)
+				if err == nil {
+					isAdmin, err := checker.isAdmin(r.Context(), token, user.IsAdmin)
+					if err != nil {
+						log.Debug("Token rejected by auth service", zap.Error(err))
+						w.WriteHeader(http.StatusUnauthorized)
+						render.JSON(w, r, response.Error("Unauthorized"))
+						return
+					}
+					ctx := WithUser(r.Context(), user.ID, user.Email, isAdmin)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+				if !errors.Is(err, errUnknownKey) {
+					log.Debug("Invalid token", zap.Error(err))
+					w.WriteHeader(http.StatusUnauthorized)
+					render.JSON(w, r, response.Error("Unauthorized"))
+					return
+				}
+			}
+
+			// TokenResponse of the shared proto has no fields for the admin
+			// flag and token scopes, and the proto has no method returning
+			// them, so the auth service sends them as the isAdminHeader and
+			// scopesHeader response headers of ValidateToken.
+			var header metadata.MD
 			resp, err := auth.ValidateToken(r.Context(), &api.TokenRequest{
-				Token: cookie.Value,
-			})
+				Token: token,
+			}, grpc.Header(&header))
 			if err != nil {
 				if status.Code(err) == codes.Unauthenticated {
 					log.Debug("Invalid token", zap.Error(err))
@@ -57,10 +135,93 @@ func New(auth Client, log *zap.SugaredLogger) func(next http.Handler) http.Handl
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), userIDKey, resp.UserId)
-			ctx = context.WithValue(ctx, userEmailKey, resp.Email)
+			isAdmin := false
+			if values := header.Get(isAdminHeader); len(values) > 0 {
+				isAdmin = values[0] == "true"
+			}
 
+			ctx := WithUser(r.Context(), resp.UserId, resp.Email, isAdmin)
+			if values := header.Get(scopesHeader); len(values) > 0 {
+				ctx = WithScopes(ctx, strings.Split(values[0], ","))
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func accessToken(r *http.Request, sources []string) (string, bool) {
+	for _, source := range sources {
+		switch source {
+		case SourceHeader:
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if ok && strings.EqualFold(scheme, "Bearer") && token != "" {
+				return token, true
+			}
+		case SourceCookie:
+			if cookie, err := r.Cookie("access_token"); err == nil && cookie.Value != "" {
+				return cookie.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// IsSource reports whether source is a place New can read the token from.
+func IsSource(source string) bool {
+	return source == SourceHeader || source == SourceCookie
+}
+
+// RequireScope lets through only requests that may use scope. It must run
+// after New.
+func RequireScope(log *zap.SugaredLogger, scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(r.Context(), scope) {
+				log.Debug("Access token lacks scope", zap.String("scope", scope))
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response.Error("insufficient scope"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, userID, email string, isAdmin bool) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	ctx = context.WithValue(ctx, userEmailKey, email)
+	return context.WithValue(ctx, userIsAdminKey, isAdmin)
+}
+
+// UserIDFromContext returns the ID of the authenticated user, if any.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok && userID != ""
+}
+
+// EmailFromContext returns the email of the authenticated user, if any.
+func EmailFromContext(ctx context.Context) (string, bool) {
+	email, ok := ctx.Value(userEmailKey).(string)
+	return email, ok && email != ""
+}
+
+// IsAdminFromContext reports whether the authenticated user is an admin.
+func IsAdminFromContext(ctx context.Context) bool {
+	isAdmin, _ := ctx.Value(userIsAdminKey).(bool)
+	return isAdmin
+}
+
+// WithScopes returns a copy of ctx limited to the scopes of a personal
+// access token.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, userScopesKey, scopes)
+}
+
+// HasScope reports whether the request may use scope. Requests
+// authenticated with a session rather than a personal access token have
+// every scope.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(userScopesKey).([]string)
+	return !ok || slices.Contains(scopes, scope)
+}
@@ -0,0 +1,402 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/Killazius/linkify-proto/pkg/api"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"linkify/internal/transport/middleware/auth"
+	"linkify/pkg/logger/zapdiscard"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type keys map[string]crypto.PublicKey
+
+func (k keys) Key(kid string) (crypto.PublicKey, bool) {
+	key, ok := k[kid]
+	return key, ok
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, exp time.Time) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"uid":   "42",
+		"email": "42@example.com",
+		"adm":   true,
+		"exp":   exp.Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// client accepts every token and answers with the header set for it.
+type client struct {
+	headers map[string]metadata.MD
+	err     error
+}
+
+func (c client) ValidateToken(_ context.Context, in *api.TokenRequest, opts ...grpc.CallOption) (*api.TokenResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	for _, opt := range opts {
+		if h, ok := opt.(grpc.HeaderCallOption); ok {
+			*h.HeaderAddr = c.headers[in.Token]
+		}
+	}
+	return &api.TokenResponse{Valid: true, UserId: in.Token, Email: in.Token + "@example.com"}, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	published := keys{"k1": &signingKey.PublicKey}
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	unknownKid := sign(t, otherKey, "k2", time.Now().Add(time.Minute))
+	demoted := sign(t, signingKey, "k1", time.Now().Add(time.Minute))
+
+	headerFirst := []string{auth.SourceHeader, auth.SourceCookie}
+	cases := []struct {
+		name          string
+		sources       []string
+		keys          auth.Keys
+		method        string
+		authorization string
+		cookie        string
+		clientErr     error
+		statusCode    int
+		userID        string
+		isAdmin       bool
+	}{
+		{
+			name:       "No token",
+			sources:    headerFirst,
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Cookie",
+			sources:    headerFirst,
+			cookie:     "cookie-jwt",
+			statusCode: http.StatusOK,
+			userID:     "cookie-jwt",
+		},
+		{
+			name:          "Header",
+			sources:       headerFirst,
+			authorization: "Bearer header-jwt",
+			statusCode:    http.StatusOK,
+			userID:        "header-jwt",
+		},
+		{
+			name:          "Lowercase scheme",
+			sources:       headerFirst,
+			authorization: "bearer header-jwt",
+			statusCode:    http.StatusOK,
+			userID:        "header-jwt",
+		},
+		{
+			name:          "Header takes precedence",
+			sources:       headerFirst,
+			authorization: "Bearer header-jwt",
+			cookie:        "cookie-jwt",
+			statusCode:    http.StatusOK,
+			userID:        "header-jwt",
+		},
+		{
+			name:          "Cookie takes precedence",
+			sources:       []string{auth.SourceCookie, auth.SourceHeader},
+			authorization: "Bearer header-jwt",
+			cookie:        "cookie-jwt",
+			statusCode:    http.StatusOK,
+			userID:        "cookie-jwt",
+		},
+		{
+			name:          "Header not allowed",
+			sources:       []string{auth.SourceCookie},
+			authorization: "Bearer header-jwt",
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "Other scheme",
+			sources:       headerFirst,
+			authorization: "Basic dXNlcjpwYXNz",
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "Read token reads",
+			sources:       headerFirst,
+			method:        http.MethodGet,
+			authorization: "Bearer lk_read",
+			statusCode:    http.StatusOK,
+			userID:        "lk_read",
+		},
+		{
+			name:          "Write token writes",
+			sources:       headerFirst,
+			method:        http.MethodPost,
+			authorization: "Bearer lk_write",
+			statusCode:    http.StatusOK,
+			userID:        "lk_write",
+		},
+		{
+			name:          "Invalid token",
+			sources:       headerFirst,
+			authorization: "Bearer header-jwt",
+			clientErr:     status.Error(codes.Unauthenticated, "invalid token"),
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "Auth service error",
+			sources:       headerFirst,
+			authorization: "Bearer header-jwt",
+			clientErr:     unavailable,
+			statusCode:    http.StatusInternalServerError,
+		},
+		{
+			name:          "Verified locally",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer " + sign(t, signingKey, "k1", time.Now().Add(time.Minute)),
+			clientErr:     unavailable,
+			statusCode:    http.StatusOK,
+			userID:        "42",
+			isAdmin:       true,
+		},
+		{
+			name:          "Rejected by auth service after local verification",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer " + sign(t, signingKey, "k1", time.Now().Add(time.Minute)),
+			clientErr:     status.Error(codes.Unauthenticated, "user not found"),
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "Admin flag taken from auth service",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer " + demoted,
+			statusCode:    http.StatusOK,
+			userID:        "42",
+		},
+		{
+			name:          "Expired locally",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer " + sign(t, signingKey, "k1", time.Now().Add(-time.Minute)),
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "Forged with published kid",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer " + sign(t, otherKey, "k1", time.Now().Add(time.Minute)),
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "Unknown kid falls back to auth service",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer " + unknownKid,
+			statusCode:    http.StatusOK,
+			userID:        unknownKid,
+		},
+		{
+			name:          "Malformed token rejected locally",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer header-jwt",
+			clientErr:     unavailable,
+			statusCode:    http.StatusUnauthorized,
+		},
+		{
+			name:          "Access token checked by auth service",
+			sources:       headerFirst,
+			keys:          published,
+			authorization: "Bearer lk_read",
+			statusCode:    http.StatusOK,
+			userID:        "lk_read",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := client{
+				headers: map[string]metadata.MD{
+					"lk_read":  metadata.Pairs("x-token-scopes", auth.ScopeLinksRead),
+					"lk_write": metadata.Pairs("x-token-scopes", auth.ScopeLinksRead+","+auth.ScopeLinksWrite),
+					demoted:    metadata.Pairs("x-is-admin", "false"),
+				},
+				err: tc.clientErr,
+			}
+			var userID, email string
+			var isAdmin bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ = auth.UserIDFromContext(r.Context())
+				email, _ = auth.EmailFromContext(r.Context())
+				isAdmin = auth.IsAdminFromContext(r.Context())
+			})
+			handler := auth.New(c, zapdiscard.New(), tc.sources, tc.keys, time.Minute)(next)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/api/urls", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: tc.cookie})
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			require.Equal(t, tc.userID, userID)
+			require.Equal(t, tc.isAdmin, isAdmin)
+			if tc.userID != "" {
+				require.Equal(t, tc.userID+"@example.com", email)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	readWrite := []string{auth.ScopeLinksRead, auth.ScopeLinksWrite}
+	cases := []struct {
+		name       string
+		scopes     []string
+		scope      string
+		statusCode int
+	}{
+		{
+			name:       "Session reads",
+			scope:      auth.ScopeLinksRead,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Session administers",
+			scope:      auth.ScopeAdmin,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Read token reads",
+			scopes:     []string{auth.ScopeLinksRead},
+			scope:      auth.ScopeLinksRead,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Read token writes",
+			scopes:     []string{auth.ScopeLinksRead},
+			scope:      auth.ScopeLinksWrite,
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "Write token writes",
+			scopes:     readWrite,
+			scope:      auth.ScopeLinksWrite,
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "Token administers",
+			scopes:     readWrite,
+			scope:      auth.ScopeAdmin,
+			statusCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			handler := auth.RequireScope(zapdiscard.New(), tc.scope)(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+			if tc.scopes != nil {
+				req = req.WithContext(auth.WithScopes(req.Context(), tc.scopes))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+		})
+	}
+}
+
+// countingClient counts the tokens it is asked to validate and fails with
+// err if set.
+type countingClient struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (c *countingClient) ValidateToken(_ context.Context, in *api.TokenRequest, _ ...grpc.CallOption) (*api.TokenResponse, error) {
+	c.calls.Add(1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &api.TokenResponse{Valid: true, UserId: "42", Email: "42@example.com"}, nil
+}
+
+func TestAuthMiddlewareRecheckInterval(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	token := sign(t, signingKey, "k1", time.Now().Add(time.Minute))
+
+	cases := []struct {
+		name     string
+		interval time.Duration
+		err      error
+		calls    int32
+	}{
+		{
+			name:     "Cached within interval",
+			interval: time.Minute,
+			calls:    1,
+		},
+		{
+			name:     "Fallback cached while auth service is down",
+			interval: time.Minute,
+			err:      status.Error(codes.Unavailable, "unavailable"),
+			calls:    1,
+		},
+		{
+			name:  "Checked every request without interval",
+			calls: 3,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := &countingClient{err: tc.err}
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			handler := auth.New(c, zapdiscard.New(), []string{auth.SourceHeader}, keys{"k1": &signingKey.PublicKey}, tc.interval)(next)
+
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/api/urls", nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				require.Equal(t, http.StatusOK, rr.Code)
+			}
+			require.Equal(t, tc.calls, c.calls.Load())
+		})
+	}
+}
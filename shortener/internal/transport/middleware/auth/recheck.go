@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/Killazius/linkify-proto/pkg/api"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// errRevoked means the auth service no longer accepts a JWT that is still
+// valid locally, for example because the account was deleted.
+var errRevoked = errors.New("token revoked")
+
+// recheckTimeout bounds a recheck, so an auth service that hangs does not
+// hold up the request for longer.
+const recheckTimeout = 2 * time.Second
+
+// rechecker confirms locally verified JWTs with the auth service at most
+// once per interval, so a deleted account or a demoted admin loses access
+// within interval instead of when the token expires. Bans are enforced by
+// the ban middleware on every request.
+type rechecker struct {
+	auth     Client
+	log      *zap.SugaredLogger
+	interval time.Duration
+
+	mu      sync.Mutex
+	checked map[string]recheck
+	swept   time.Time
+}
+
+type recheck struct {
+	isAdmin bool
+	at      time.Time
+}
+
+func newRechecker(auth Client, log *zap.SugaredLogger, interval time.Duration) *rechecker {
+	return &rechecker{
+		auth:     auth,
+		log:      log,
+		interval: interval,
+		checked:  make(map[string]recheck),
+	}
+}
+
+// isAdmin returns the current admin flag of the token's user. When the auth
+// service is unreachable it falls back to claimed, the flag in the token,
+// so that local verification keeps the API up during its outages. The
+// fallback is kept for interval too, so an outage costs one timed-out call
+// per token and interval rather than one per request.
+func (c *rechecker) isAdmin(ctx context.Context, token string, claimed bool) (bool, error) {
+	now := time.Now()
+	if rc, ok := c.lookup(token, now); ok {
+		return rc.isAdmin, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, recheckTimeout)
+	defer cancel()
+	var header metadata.MD
+	resp, err := c.auth.ValidateToken(ctx, &api.TokenRequest{
+		Token: token,
+	}, grpc.Header(&header))
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unauthenticated, codes.InvalidArgument:
+			return false, errRevoked
+		}
+		c.log.Warn("Failed to recheck token, using its claims", zap.Error(err))
+		c.store(token, recheck{isAdmin: claimed, at: now})
+		return claimed, nil
+	}
+	if !resp.Valid {
+		return false, errRevoked
+	}
+
+	isAdmin := false
+	if values := header.Get(isAdminHeader); len(values) > 0 {
+		isAdmin = values[0] == "true"
+	}
+	c.store(token, recheck{isAdmin: isAdmin, at: now})
+	return isAdmin, nil
+}
+
+func (c *rechecker) lookup(token string, now time.Time) (recheck, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rc, ok := c.checked[token]
+	return rc, ok && now.Sub(rc.at) < c.interval
+}
+
+// store records rc and drops stale results once per interval, so the map
+// holds at most the tokens seen during the last two intervals.
+func (c *rechecker) store(token string, rc recheck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if rc.at.Sub(c.swept) >= c.interval {
+		for t, old := range c.checked {
+			if rc.at.Sub(old.at) >= c.interval {
+				delete(c.checked, t)
+			}
+		}
+		c.swept = rc.at
+	}
+	c.checked[token] = rc
+}
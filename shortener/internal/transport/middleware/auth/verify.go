@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// errUnknownKey means the token was not signed with any of the published
+// keys, for example with the shared HS256 secret, so only the auth service
+// can verify it.
+var errUnknownKey = errors.New("unknown signing key")
+
+type claims struct {
+	ID      string `json:"uid"`
+	Email   string `json:"email"`
+	IsAdmin bool   `json:"adm"`
+	jwt.RegisteredClaims
+}
+
+func verify(token string, keys Keys) (*claims, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys.Key(kid)
+		if !ok {
+			return nil, errUnknownKey
+		}
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA:
+			if _, ok := key.(*rsa.PublicKey); ok {
+				return key, nil
+			}
+		case *jwt.SigningMethodEd25519:
+			if _, ok := key.(ed25519.PublicKey); ok {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("key %s does not match signing method %v", kid, t.Header["alg"])
+	}, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == "" {
+		return nil, errors.New("invalid uid claim")
+	}
+	return &c, nil
+}
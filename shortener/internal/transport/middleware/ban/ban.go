@@ -0,0 +1,41 @@
+package ban
+
+import (
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+	"linkify/internal/lib/api/response"
+	"linkify/internal/transport/middleware/auth"
+	"net/http"
+)
+
+type Checker interface {
+	IsBanned(ownerID string) (bool, error)
+}
+
+// New rejects requests of banned users. It must run after the auth
+// middleware.
+func New(checker Checker, log *zap.SugaredLogger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := auth.UserIDFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			banned, err := checker.IsBanned(userID)
+			if err != nil {
+				log.Error("Failed to check ban", zap.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal server error"))
+				return
+			}
+			if banned {
+				log.Infow("request of banned user", "user_id", userID)
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response.Error("account is banned"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package transport
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"github.com/Killazius/linkify-proto/pkg/api"
@@ -12,11 +14,30 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"linkify/internal/config"
+	"linkify/internal/lib/unfurl"
 	"linkify/internal/metrics"
+	"linkify/internal/screening"
+	"linkify/internal/storage"
+	"linkify/internal/transport/handlers/admin/bans"
+	"linkify/internal/transport/handlers/admin/links"
+	"linkify/internal/transport/handlers/admin/reports"
 	"linkify/internal/transport/handlers/url/delete"
+	"linkify/internal/transport/handlers/url/export"
+	"linkify/internal/transport/handlers/url/get"
+	"linkify/internal/transport/handlers/url/list"
+	"linkify/internal/transport/handlers/url/meta"
+	"linkify/internal/transport/handlers/url/qr"
 	"linkify/internal/transport/handlers/url/redirect"
+	"linkify/internal/transport/handlers/url/report"
+	"linkify/internal/transport/handlers/url/rules"
 	"linkify/internal/transport/handlers/url/save"
+	"linkify/internal/transport/handlers/url/schedule"
+	"linkify/internal/transport/handlers/url/stats"
+	"linkify/internal/transport/handlers/url/update"
+	"linkify/internal/transport/handlers/url/variants"
+	"linkify/internal/transport/middleware/admin"
 	"linkify/internal/transport/middleware/auth"
+	"linkify/internal/transport/middleware/ban"
 	customLogger "linkify/internal/transport/middleware/customLogger"
 	"linkify/internal/transport/middleware/httpmetrics"
 	"net/http"
@@ -24,31 +45,59 @@ import (
 )
 
 type Repository interface {
-	Save(urlToSave string, alias string, createdAt time.Time) error
-	Get(alias string) (string, error)
-	Delete(alias string) error
+	Save(link storage.Link) error
+	Get(alias string) (*storage.Link, error)
+	ConsumeClick(alias string) (*storage.Link, error)
+	Delete(alias string, ownerID string, isAdmin bool) error
+	SaveBatch(links []storage.Link) ([]error, error)
+	DeleteBatch(aliases []string, ownerID string, isAdmin bool) ([]error, error)
+	Update(alias string, ownerID string, isAdmin bool, version int, update storage.LinkUpdate) (*storage.Link, error)
+	ListByOwner(filter storage.ListFilter) (*storage.LinkPage, error)
+	ExportByOwner(ctx context.Context, ownerID string, fn func(link storage.ExportedLink) error) error
+	ClickStats(alias string, ownerID string, isAdmin bool, filter storage.StatsFilter) (*storage.ClickStats, error)
+	SaveReport(report storage.Report) error
+	ListReports(filter storage.ReportFilter) (*storage.ReportPage, error)
+	BanOwner(ban storage.Ban) ([]string, error)
+	UnbanOwner(ownerID string) error
+	IsBanned(ownerID string) (bool, error)
 	Stop() error
 }
 
 type Cache interface {
-	Set(ctx context.Context, key string, value string, expiration time.Duration) error
-	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, link storage.Link, expiration time.Duration) error
+	Get(ctx context.Context, key string) (*storage.Link, error)
 	Delete(ctx context.Context, key string) error
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error)
 	Stop() error
 }
+type ClickTracker interface {
+	Track(click storage.Click)
+	Stop(ctx context.Context)
+}
+type GeoIP interface {
+	Country(ip string) string
+}
 type Auth interface {
 	ValidateToken(ctx context.Context, in *api.TokenRequest, opts ...grpc.CallOption) (*api.TokenResponse, error)
 }
+type SigningKeys interface {
+	Key(kid string) (crypto.PublicKey, bool)
+}
 
 type Server struct {
-	server  *http.Server
-	router  *chi.Mux
-	log     *zap.SugaredLogger
-	repo    Repository
-	cache   Cache
-	metrics *metrics.Collector
-	config  config.HTTPServer
-	client  Auth
+	server   *http.Server
+	router   *chi.Mux
+	log      *zap.SugaredLogger
+	repo     Repository
+	cache    Cache
+	metrics  *metrics.Collector
+	config   config.HTTPServer
+	auth     config.Auth
+	client   Auth
+	keys     SigningKeys
+	clicks   ClickTracker
+	geoip    GeoIP
+	screener *screening.Screener
 }
 
 func New(
@@ -57,8 +106,12 @@ func New(
 	repo Repository,
 	cache Cache,
 	metrics *metrics.Collector,
+	authCfg config.Auth,
 	client Auth,
-
+	keys SigningKeys,
+	clicks ClickTracker,
+	geoip GeoIP,
+	screener *screening.Screener,
 ) *Server {
 	router := chi.NewRouter()
 	srv := &Server{
@@ -69,13 +122,18 @@ func New(
 			IdleTimeout:  cfg.IdleTimeout,
 			Handler:      router,
 		},
-		router:  router,
-		log:     log,
-		repo:    repo,
-		cache:   cache,
-		metrics: metrics,
-		config:  cfg,
-		client:  client,
+		router:   router,
+		log:      log,
+		repo:     repo,
+		cache:    cache,
+		metrics:  metrics,
+		config:   cfg,
+		auth:     authCfg,
+		client:   client,
+		keys:     keys,
+		clicks:   clicks,
+		geoip:    geoip,
+		screener: screener,
 	}
 
 	srv.registerRoutes()
@@ -90,22 +148,100 @@ func (s *Server) registerRoutes() {
 	s.router.Use(middleware.URLFormat)
 	s.router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://127.0.0.1"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match"},
+		ExposedHeaders:   []string{"Link", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 	s.router.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL(fmt.Sprintf("http://%s/swagger/doc.json", s.config.IP)),
 	))
-	s.router.Get("/{alias}", redirect.New(s.log, s.repo, s.cache, s.metrics))
+	redirectHandler := redirect.New(s.log, s.repo, s.cache, s.metrics, s.clicks, s.redirectConfig())
+	s.router.Get("/{alias:[A-Za-z0-9_-]+}", redirectHandler)
+	s.router.Post("/{alias:[A-Za-z0-9_-]+}", redirectHandler)
+	s.router.Get("/{alias:[A-Za-z0-9_-]+}+", redirect.NewPreview(s.log, s.repo, s.cache))
+	s.router.Post("/{alias:[A-Za-z0-9_-]+}/report", report.New(s.log, s.repo, s.cache, report.Limit{
+		Reports: s.config.ReportRateLimit,
+		Window:  s.config.ReportRateWindow,
+	}))
 
-	s.router.With(auth.New(s.client, s.log)).Route("/api", func(r chi.Router) {
-		r.Post("/url", save.New(s.log, s.repo, s.cache, s.config.AliasLength, s.metrics))
-		r.Delete("/url/{alias}", delete.New(s.log, s.repo, s.cache, s.metrics))
+	read := auth.RequireScope(s.log, auth.ScopeLinksRead)
+	write := auth.RequireScope(s.log, auth.ScopeLinksWrite)
+	s.router.With(auth.New(s.client, s.log, s.tokenSources(), s.keys, s.auth.RecheckInterval), ban.New(s.repo, s.log)).Route("/api", func(r chi.Router) {
+		r.With(write).Post("/url", save.New(s.log, s.repo, s.cache, s.aliasRules(), s.screener, s.metrics))
+		r.With(read).Get("/url/{alias}", get.New(s.log, s.repo))
+		r.With(write).Patch("/url/{alias}", update.New(s.log, s.repo, s.cache, s.screener))
+		r.With(write).Delete("/url/{alias}", delete.New(s.log, s.repo, s.cache, s.metrics))
+		r.With(write).Put("/url/{alias}/meta", meta.New(s.log, s.repo, s.repo, s.cache, s.metaFetcher(), s.screener))
+		r.With(write).Put("/url/{alias}/rules", rules.New(s.log, s.repo, s.cache, s.screener))
+		r.With(write).Put("/url/{alias}/variants", variants.New(s.log, s.repo, s.cache, s.screener))
+		r.With(write).Put("/url/{alias}/schedule", schedule.New(s.log, s.repo, s.cache))
+		r.With(read).Get("/url/{alias}/stats", stats.New(s.log, s.repo))
+		r.With(read).Get("/url/{alias}/qr", qr.New(s.log, s.repo, s.config.BaseURL()))
+		r.With(read).Get("/urls", list.New(s.log, s.repo))
+		r.With(write).Post("/urls/batch", save.NewBatch(s.log, s.repo, s.aliasRules(), s.screener, s.config.BatchLimit, s.metrics))
+		r.With(write).Post("/urls/batch-delete", delete.NewBatch(s.log, s.repo, s.cache, s.config.BatchLimit, s.metrics))
+		r.With(read).Get("/urls/export", export.New(s.log, s.repo))
+		r.With(write).Post("/urls/import", save.NewImport(s.log, s.repo, s.aliasRules(), s.screener, s.config.BatchLimit, s.metrics))
+
+		r.With(auth.RequireScope(s.log, auth.ScopeAdmin), admin.New(s.log)).Route("/admin", func(r chi.Router) {
+			r.Get("/reports", reports.New(s.log, s.repo))
+			r.Post("/url/{alias}/disable", links.New(s.log, s.repo, s.cache, true))
+			r.Post("/url/{alias}/enable", links.New(s.log, s.repo, s.cache, false))
+			r.Post("/owners/{owner_id}/ban", bans.New(s.log, s.repo, s.cache))
+			r.Delete("/owners/{owner_id}/ban", bans.NewUnban(s.log, s.repo))
+		})
 	})
 }
+func (s *Server) aliasRules() save.AliasRules {
+	return save.AliasRules{
+		Length:    s.config.AliasLength,
+		MinLength: s.config.CustomAliasMinLength,
+		MaxLength: s.config.CustomAliasMaxLength,
+		Reserved:  s.config.ReservedAliases,
+	}
+}
+
+func (s *Server) tokenSources() []string {
+	if len(s.auth.TokenSources) == 0 {
+		s.log.Fatal("no auth token sources configured")
+	}
+	for _, source := range s.auth.TokenSources {
+		if !auth.IsSource(source) {
+			s.log.Fatalw("invalid auth token source", "token_source", source)
+		}
+	}
+	return s.auth.TokenSources
+}
+
+func (s *Server) metaFetcher() *unfurl.Fetcher {
+	return unfurl.NewFetcher(unfurl.NewClient(s.config.MetaFetchTimeout), s.config.MetaFetchMaxBytes)
+}
+
+func (s *Server) redirectConfig() redirect.Config {
+	secret := []byte(s.config.LinkCookieSecret)
+	if len(secret) == 0 {
+		s.log.Warn("LINK_COOKIE_SECRET is not set, password cookies will not survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			s.log.Fatal("failed to generate cookie secret", zap.Error(err))
+		}
+	}
+	if !storage.IsRedirectType(s.config.DefaultRedirectType) {
+		s.log.Fatalw("invalid default redirect type", "default_redirect_type", s.config.DefaultRedirectType)
+	}
+	return redirect.Config{
+		CookieSecret:          secret,
+		CookieTTL:             s.config.LinkCookieTTL,
+		PendingPage:           s.config.PendingLinkResponse != config.PendingLinkNotFound,
+		Countries:             s.geoip,
+		VariantCookieTTL:      s.config.VariantCookieTTL,
+		DefaultStatus:         s.config.DefaultRedirectType,
+		PermanentCacheControl: s.config.PermanentCacheControl,
+	}
+}
+
 func (s *Server) MustRun() {
 	if err := s.Run(); err != nil {
 		s.log.Fatal("failed to run HTTP-server", zap.Error(err))
@@ -123,6 +259,7 @@ func (s *Server) Stop(ctx context.Context) {
 	if err := s.server.Shutdown(ctx); err != nil {
 		s.log.Error("failed to stop HTTP server", zap.Error(err))
 	}
+	s.clicks.Stop(ctx)
 	err := s.cache.Stop()
 	if err != nil {
 		s.log.Error("failed to stop redis client", zap.Error(err))